@@ -0,0 +1,63 @@
+// token mints JWTs for local development and manual testing, using the same keys loan-service is configured with.
+//
+//	go run ./cmd/token -role staff -sub 1e938a3c-3752-49a6-a2a6-43be38c6aa82
+//	go run ./cmd/token -role investor -sub e217fd14-0de2-4a11-8989-d8d51e2b9886 -key keys/jwt_private.pem
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/config"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/jwt"
+)
+
+func main() {
+	role := flag.String("role", "", "caller role: staff, borrower or investor")
+	sub := flag.String("sub", "", "caller ID (UUID)")
+	ttl := flag.Duration("ttl", time.Hour, "token lifetime")
+	keyFile := flag.String("key", "", "RSA private key (PEM) to sign RS256 tokens, HS256 with JWT_HMAC_SECRET otherwise")
+	flag.Parse()
+
+	if *role == "" || *sub == "" {
+		log.Fatal("-role and -sub are required")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %s", err.Error())
+	}
+
+	now := time.Now()
+	claims := jwt.Claims{
+		Subject:   *sub,
+		Role:      *role,
+		Issuer:    cfg.JWTIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(*ttl).Unix(),
+	}
+
+	var token string
+	if *keyFile != "" {
+		key, err := jwt.LoadRSAPrivateKey(*keyFile)
+		if err != nil {
+			log.Fatalf("Error loading private key: %s", err.Error())
+		}
+		token, err = jwt.SignRS256(claims, key)
+		if err != nil {
+			log.Fatalf("Error signing token: %s", err.Error())
+		}
+	} else {
+		if cfg.JWTHMACSecret == "" {
+			log.Fatal("JWT_HMAC_SECRET is empty, set it or pass -key")
+		}
+		token, err = jwt.SignHS256(claims, []byte(cfg.JWTHMACSecret))
+		if err != nil {
+			log.Fatalf("Error signing token: %s", err.Error())
+		}
+	}
+
+	fmt.Println(token)
+}
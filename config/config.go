@@ -20,6 +20,64 @@ type (
 		RedisUsername string   `mapstructure:"REDIS_USERNAME"`
 		RedisPassword string   `mapstructure:"REDIS_PASSWORD"`
 
+		// Auth: HS256 tokens are verified with the HMAC secret, RS256 tokens with the PEM public key file
+		JWTHMACSecret       string `mapstructure:"JWT_HMAC_SECRET"`
+		JWTRSAPublicKeyFile string `mapstructure:"JWT_RSA_PUBLIC_KEY_FILE"`
+		JWTIssuer           string `mapstructure:"JWT_ISSUER"`
+		JWTLeewaySeconds    int    `mapstructure:"JWT_LEEWAY_SECONDS"`
+
+		// Repayment schedule: months between disbursement and the first instalment due date
+		FirstDueAfterMonths int `mapstructure:"FIRST_DUE_AFTER_MONTHS"`
+
+		// Funding: days an approved loan stays open for investment, and seconds between two runs of the expiry job
+		FundingWindowDays         int `mapstructure:"FUNDING_WINDOW_DAYS"`
+		LoanExpiryIntervalSeconds int `mapstructure:"LOAN_EXPIRY_INTERVAL_SECONDS"`
+
+		// Investment limits, 0 / empty disables a rule: amounts in minor units, max loan share as a percentage e.g. "25.00"
+		InvestmentMinTicket    int64  `mapstructure:"INVESTMENT_MIN_TICKET"`
+		InvestmentStep         int64  `mapstructure:"INVESTMENT_STEP"`
+		InvestmentMaxLoanShare string `mapstructure:"INVESTMENT_MAX_LOAN_SHARE"`
+		InvestmentMaxExposure  int64  `mapstructure:"INVESTMENT_MAX_EXPOSURE"`
+
+		// Idempotency: hours an Idempotency-Key is remembered, 24 when unset
+		IdempotencyKeyTTLHours int `mapstructure:"IDEMPOTENCY_KEY_TTL_HOURS"`
+
+		// Outbox relay: seconds between two runs, events published per run, and where they are published to,
+		// "file" appends them as JSON lines to OUTBOX_FILE
+		OutboxRelayIntervalSeconds int    `mapstructure:"OUTBOX_RELAY_INTERVAL_SECONDS"`
+		OutboxBatchSize            int    `mapstructure:"OUTBOX_BATCH_SIZE"`
+		OutboxPublisher            string `mapstructure:"OUTBOX_PUBLISHER"`
+		OutboxFile                 string `mapstructure:"OUTBOX_FILE"`
+
+		// Documents: "local" keeps them under DOCUMENT_LOCAL_DIR and serves signed URLs from DOCUMENT_BASE_URL,
+		// "s3" keeps them in an S3-compatible bucket and presigns its URLs
+		DocumentStore         string `mapstructure:"DOCUMENT_STORE"`
+		DocumentLocalDir      string `mapstructure:"DOCUMENT_LOCAL_DIR"`
+		DocumentBaseURL       string `mapstructure:"DOCUMENT_BASE_URL"`
+		DocumentURLSecret     string `mapstructure:"DOCUMENT_URL_SECRET"`
+		DocumentURLTTLSeconds int    `mapstructure:"DOCUMENT_URL_TTL_SECONDS"`
+		S3Endpoint            string `mapstructure:"S3_ENDPOINT"`
+		S3Region              string `mapstructure:"S3_REGION"`
+		S3Bucket              string `mapstructure:"S3_BUCKET"`
+		S3AccessKeyID         string `mapstructure:"S3_ACCESS_KEY_ID"`
+		S3SecretAccessKey     string `mapstructure:"S3_SECRET_ACCESS_KEY"`
+		S3PathStyle           bool   `mapstructure:"S3_PATH_STYLE"`
+
+		// Notifications: e-mails go through the SMTP relay, none are sent when SMTP_HOST is empty. Documents are
+		// linked in them with signed URLs valid for NOTIFICATION_LINK_TTL_HOURS (7 days when 0, the most S3 allows)
+		SMTPHost                    string `mapstructure:"SMTP_HOST"`
+		SMTPPort                    int    `mapstructure:"SMTP_PORT"`
+		SMTPUsername                string `mapstructure:"SMTP_USERNAME"`
+		SMTPPassword                string `mapstructure:"SMTP_PASSWORD"`
+		SMTPFrom                    string `mapstructure:"SMTP_FROM"`
+		NotificationLinkTTLHours    int    `mapstructure:"NOTIFICATION_LINK_TTL_HOURS"`
+		NotificationIntervalSeconds int    `mapstructure:"NOTIFICATION_INTERVAL_SECONDS"`
+		NotificationBatchSize       int    `mapstructure:"NOTIFICATION_BATCH_SIZE"`
+
+		// Webhooks: seconds between two runs of the delivery job and deliveries posted per run, sent with the HTTP client below
+		WebhookIntervalSeconds int `mapstructure:"WEBHOOK_INTERVAL_SECONDS"`
+		WebhookBatchSize       int `mapstructure:"WEBHOOK_BATCH_SIZE"`
+
 		// HTTP client
 		HttpClientTimeout             int  `mapstructure:"HTTP_CLIENT_TIMEOUT"`
 		HttpClientDisableKeepAlives   bool `mapstructure:"HTTP_CLIENT_DISABLE_KEEP_ALIVE"`
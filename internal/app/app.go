@@ -1,13 +1,22 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	gintrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gin-gonic/gin"
 
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/eventbus"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/jwt"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/mail"
 	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/storage"
 	grace "github.com/ferdikurniawan/loan-service/internal/utils/grace"
 
 	"github.com/ferdikurniawan/loan-service/config"
@@ -34,12 +43,56 @@ func Run(config *config.Config) {
 		log.Fatalf("error init postgres %s", err.Error())
 	}
 
+	authenticator, err := newAuthenticator(config)
+	if err != nil {
+		log.Fatalf("error init authenticator %s", err.Error())
+	}
+
+	limits, err := investmentLimits(config)
+	if err != nil {
+		log.Fatalf("error init investment limits %s", err.Error())
+	}
+
+	httpClient := newHTTPClient(config)
+
+	documents, localDocuments, err := newDocumentStore(config, httpClient)
+	if err != nil {
+		log.Fatalf("error init document store %s", err.Error())
+	}
+
+	publisher, err := newEventPublisher(config)
+	if err != nil {
+		log.Fatalf("error init event publisher %s", err.Error())
+	}
+
+	sender, err := newEmailSender(config)
+	if err != nil {
+		log.Fatalf("error init e-mail sender %s", err.Error())
+	}
+
 	// services layer
-	loanService := services.NewLoanService(repo.NewLoanRepo(pg))
+	loanService := services.NewLoanService(repo.NewLoanRepo(pg),
+		services.WithFirstDueAfterMonths(config.FirstDueAfterMonths),
+		services.WithFundingWindowDays(config.FundingWindowDays),
+		services.WithInvestmentLimits(limits),
+		services.WithDocumentStore(documents, time.Duration(config.DocumentURLTTLSeconds)*time.Second),
+	)
+	ledgerService := services.NewLedgerService(repo.NewLedgerRepo(pg))
+	walletService := services.NewWalletService(repo.NewWalletRepo(pg))
+	outboxService := services.NewOutboxService(repo.NewOutboxRepo(pg), publisher, config.OutboxBatchSize)
+	notificationService := services.NewNotificationService(repo.NewNotificationRepo(pg), sender,
+		documents, time.Duration(config.NotificationLinkTTLHours)*time.Hour, config.NotificationBatchSize,
+	)
+	webhookService := services.NewWebhookService(repo.NewWebhookRepo(pg), httpClient, config.WebhookBatchSize)
+	idempotencyService := services.NewIdempotencyService(repo.NewIdempotencyRepo(pg),
+		time.Duration(config.IdempotencyKeyTTLHours)*time.Hour,
+	)
 
 	// gin
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
+	//handlers pass the gin context to the services, let it answer for the request context e.g. its request ID
+	handler.ContextWithFallback = true
 
 	// middlewares
 	handler.Use(gintrace.Middleware(ServiceName))
@@ -48,9 +101,143 @@ func Run(config *config.Config) {
 	handler.Use(gin.Recovery())
 
 	v1.NewRouter(handler, v1.Services{
-		Cfg:         config,
-		LoanService: loanService,
+		Cfg:           config,
+		Authenticator: authenticator,
+		LoanService:   loanService,
+		LedgerService: ledgerService,
+		WalletService: walletService,
+
+		NotificationService: notificationService,
+		WebhookService:      webhookService,
+		IdempotencyService:  idempotencyService,
 	})
+	if localDocuments != nil {
+		v1.NewDocumentRouter(handler, localDocuments)
+	}
+
+	// background jobs, stopped once the server has shut down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runLoanExpiry(jobsCtx, loanService, time.Duration(config.LoanExpiryIntervalSeconds)*time.Second)
+	go runOutboxRelay(jobsCtx, outboxService, time.Duration(config.OutboxRelayIntervalSeconds)*time.Second)
+	go runWebhookDeliveries(jobsCtx, webhookService, time.Duration(config.WebhookIntervalSeconds)*time.Second)
+	if sender != nil {
+		go runNotifications(jobsCtx, notificationService, time.Duration(config.NotificationIntervalSeconds)*time.Second)
+	} else {
+		log.Printf("SMTP_HOST is not set, notifications are queued but not sent")
+	}
 
 	grace.Serve(config.Port, handler)
 }
+
+func investmentLimits(config *config.Config) (services.InvestmentLimits, error) {
+	limits := services.InvestmentLimits{
+		MinTicket:   config.InvestmentMinTicket,
+		Step:        config.InvestmentStep,
+		MaxExposure: config.InvestmentMaxExposure,
+	}
+
+	if config.InvestmentMaxLoanShare != "" {
+		share, err := entity.ParseRate(config.InvestmentMaxLoanShare)
+		if err != nil {
+			return limits, err
+		}
+		limits.MaxLoanShare = share
+	}
+
+	return limits, nil
+}
+
+// newDocumentStore builds the configured document store, the local one is returned a second time as it also
+// serves its signed URLs
+func newDocumentStore(config *config.Config, client *http.Client) (services.DocumentStore, *storage.Local, error) {
+	switch config.DocumentStore {
+	case "s3":
+		store, err := storage.NewS3(storage.S3Config{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyID:     config.S3AccessKeyID,
+			SecretAccessKey: config.S3SecretAccessKey,
+			PathStyle:       config.S3PathStyle,
+			Client:          client,
+		})
+		return store, nil, err
+	case "", "local":
+		dir := config.DocumentLocalDir
+		if dir == "" {
+			dir = "./uploads"
+		}
+		store, err := storage.NewLocal(dir, config.DocumentBaseURL, []byte(config.DocumentURLSecret))
+		return store, store, err
+	}
+	return nil, nil, fmt.Errorf("unknown document store %q", config.DocumentStore)
+}
+
+func newEventPublisher(config *config.Config) (services.EventPublisher, error) {
+	switch config.OutboxPublisher {
+	case "", "file":
+		name := config.OutboxFile
+		if name == "" {
+			name = "./events/loan-events.jsonl"
+		}
+		return eventbus.NewFile(name)
+	case "memory":
+		return eventbus.NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown outbox publisher %q", config.OutboxPublisher)
+}
+
+// newEmailSender builds the SMTP sender, nil when no SMTP relay is configured
+func newEmailSender(config *config.Config) (services.EmailSender, error) {
+	if config.SMTPHost == "" {
+		return nil, nil
+	}
+	return mail.NewSMTP(mail.Config{
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		From:     config.SMTPFrom,
+	})
+}
+
+func newHTTPClient(config *config.Config) *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(config.HttpClientTimeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DisableKeepAlives:   config.HttpClientDisableKeepAlives,
+			MaxIdleConns:        config.HttpClientMaxIdleConns,
+			MaxConnsPerHost:     config.HttpClientMaxConnsPerHost,
+			MaxIdleConnsPerHost: config.HttpClientMaxIdleConnsPerHost,
+			IdleConnTimeout:     time.Duration(config.HttpClientIdleConnTimeout) * time.Second,
+		},
+	}
+}
+
+func newAuthenticator(config *config.Config) (v1.Authenticator, error) {
+	opts := []jwt.Option{
+		jwt.WithIssuer(config.JWTIssuer),
+		jwt.WithLeeway(time.Duration(config.JWTLeewaySeconds) * time.Second),
+	}
+
+	if config.JWTHMACSecret != "" {
+		opts = append(opts, jwt.WithHMACSecret([]byte(config.JWTHMACSecret)))
+	}
+
+	if config.JWTRSAPublicKeyFile != "" {
+		key, err := jwt.LoadRSAPublicKey(config.JWTRSAPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, jwt.WithRSAPublicKey(key))
+	}
+
+	verifier, err := jwt.NewVerifier(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return v1.NewJWTAuthenticator(verifier), nil
+}
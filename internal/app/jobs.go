@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+const (
+	defaultLoanExpiryInterval   = time.Minute
+	defaultOutboxRelayInterval  = 5 * time.Second
+	defaultNotificationInterval = 30 * time.Second
+	defaultWebhookInterval      = 10 * time.Second
+)
+
+// runLoanExpiry expires overdue loans every interval until ctx is done. Running it in several app
+// instances is safe, a loan expired by one of them is skipped by the others.
+func runLoanExpiry(ctx context.Context, svc services.LoanService, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultLoanExpiryInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := svc.ExpireOverdueLoans(ctx)
+			if err != nil {
+				log.Printf("[runLoanExpiry] error expiring loans: %s", err.Error())
+			}
+			if expired > 0 {
+				log.Printf("[runLoanExpiry] expired %d loans", expired)
+			}
+		}
+	}
+}
+
+// runOutboxRelay publishes pending outbox events every interval until ctx is done. Several app instances share the
+// work, an event claimed by one of them is left alone by the others.
+func runOutboxRelay(ctx context.Context, svc services.OutboxService, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultOutboxRelayInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.RelayEvents(ctx); err != nil {
+				log.Printf("[runOutboxRelay] error relaying events: %s", err.Error())
+			}
+		}
+	}
+}
+
+// runNotifications sends the pending notifications every interval until ctx is done. Like the outbox relay, several app
+// instances can run it side by side.
+func runNotifications(ctx context.Context, svc services.NotificationService, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultNotificationInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := svc.SendPending(ctx)
+			if err != nil {
+				log.Printf("[runNotifications] error sending notifications: %s", err.Error())
+			}
+			if sent > 0 {
+				log.Printf("[runNotifications] sent %d notifications", sent)
+			}
+		}
+	}
+}
+
+// runWebhookDeliveries posts the pending webhook deliveries every interval until ctx is done, several app instances
+// can run it side by side
+func runWebhookDeliveries(ctx context.Context, svc services.WebhookService, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWebhookInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.DeliverPending(ctx); err != nil {
+				log.Printf("[runWebhookDeliveries] error delivering webhooks: %s", err.Error())
+			}
+		}
+	}
+}
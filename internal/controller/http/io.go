@@ -1,10 +1,22 @@
 package http
 
 import (
+	"net/http"
+
 	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
 	"github.com/gin-gonic/gin"
 )
 
+// statusByKind maps domain error kinds to HTTP status codes
+var statusByKind = map[apperror.Kind]int{
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindValidation:   http.StatusUnprocessableEntity,
+	apperror.KindInvalidState: http.StatusUnprocessableEntity,
+	apperror.KindForbidden:    http.StatusForbidden,
+}
+
 func Response(c *gin.Context, success bool, err *entity.ErrorResponse, data any, httpStatus int) {
 	c.JSON(httpStatus, gin.H{
 		"success": success,
@@ -12,3 +24,30 @@ func Response(c *gin.Context, success bool, err *entity.ErrorResponse, data any,
 		"data":    data,
 	})
 }
+
+// ErrorResponse writes err returned by the service layer. Domain errors keep their code as ErrorResponse.Type,
+// anything else is hidden behind a generic 500
+func ErrorResponse(c *gin.Context, err error) {
+	appErr, ok := apperror.As(err)
+	if !ok {
+		Response(c,
+			false,
+			&entity.ErrorResponse{Code: http.StatusInternalServerError, Type: "server_error", Message: "internal server error"},
+			nil,
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	status, ok := statusByKind[appErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	Response(c,
+		false,
+		&entity.ErrorResponse{Code: status, Type: appErr.Code, Message: appErr.Message},
+		nil,
+		status,
+	)
+}
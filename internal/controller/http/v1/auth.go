@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/jwt"
+)
+
+var errMissingCredentials = errors.New("missing bearer token")
+
+// Authenticator resolves the caller of a request, implementations decide which credential they accept
+type Authenticator interface {
+	Authenticate(r *http.Request) (*entity.Principal, error)
+}
+
+// contextKeyByRole keeps the gin context keys the handlers read the caller's ID from
+var contextKeyByRole = map[string]string{
+	entity.RoleStaff:    "staffID",
+	entity.RoleBorrower: "borrowerID",
+	entity.RoleInvestor: "investorID",
+}
+
+type jwtAuthenticator struct {
+	verifier *jwt.Verifier
+}
+
+// NewJWTAuthenticator authenticates `Authorization: Bearer <jwt>` requests, the token subject must be the caller's UUID
+func NewJWTAuthenticator(verifier *jwt.Verifier) Authenticator {
+	return &jwtAuthenticator{verifier}
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*entity.Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, errMissingCredentials
+	}
+
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, errors.New("token subject is not a valid ID")
+	}
+	if _, ok := contextKeyByRole[claims.Role]; !ok {
+		return nil, errors.New("token role is not recognized")
+	}
+
+	return &entity.Principal{Subject: claims.Subject, Role: claims.Role}, nil
+}
+
+// AuthMiddleware rejects unauthenticated requests and exposes the caller as `principal`, `role`
+// and one of `staffID` / `borrowerID` / `investorID` in the gin context
+func AuthMiddleware(authn Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authn.Authenticate(c.Request)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 401, Type: "unauthorized", Message: err.Error()},
+				nil,
+				http.StatusUnauthorized,
+			)
+			c.Abort()
+			return
+		}
+
+		c.Set("principal", principal)
+		c.Set("role", principal.Role)
+		c.Set(contextKeyByRole[principal.Role], principal.Subject)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/storage"
+)
+
+// DocumentServer opens the document a signed URL of the local document store points to
+type DocumentServer interface {
+	Open(key string, expires int64, signature string) (*os.File, error)
+}
+
+type documentRoutes struct {
+	documents DocumentServer
+}
+
+// NewDocumentRouter serves the signed download URLs of the local document store. The signature is the credential,
+// so the route sits outside the bearer token authentication.
+func NewDocumentRouter(handler *gin.Engine, documents DocumentServer) {
+	r := &documentRoutes{documents}
+
+	handler.GET(storage.LocalPathPrefix+"*key", r.getDocument) //download a document through a signed URL
+}
+
+func (r *documentRoutes) getDocument(c *gin.Context) {
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || c.Query("signature") == "" {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (expires, signature)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f, err := r.documents.Open(key, expires, c.Query("signature"))
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		httpHelper.ErrorResponse(c, apperror.ErrDocumentNotFound)
+		return
+	case errors.Is(err, storage.ErrInvalidKey), errors.Is(err, storage.ErrInvalidSignature), errors.Is(err, storage.ErrURLExpired):
+		httpHelper.ErrorResponse(c, apperror.ErrForbidden)
+		return
+	case err != nil:
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), f)
+}
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/pkg/storage"
+)
+
+func Test_GetDocument(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewLocal(t.TempDir(), "http://localhost:8080", []byte("secret"))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	assert.Nil(t, store.Put(ctx, "agreements/0a1b.pdf", "application/pdf", []byte("%PDF-1.4")))
+	signed, err := store.SignedURL(ctx, "agreements/0a1b.pdf", time.Minute)
+	assert.Nil(t, err)
+	missing, err := store.SignedURL(ctx, "agreements/2c3d.pdf", time.Minute)
+	assert.Nil(t, err)
+
+	handler := gin.New()
+	NewDocumentRouter(handler, store)
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(target, "http://localhost:8080"), nil))
+		return w
+	}
+
+	w := serve(signed)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "%PDF-1.4", w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+
+	assert.Equal(t, http.StatusForbidden, serve(strings.Replace(signed, "signature=", "signature=0", 1)).Code)
+	assert.Equal(t, http.StatusNotFound, serve(missing).Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/v1/documents/agreements/0a1b.pdf").Code)
+}
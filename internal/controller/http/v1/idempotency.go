@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotent makes a mutating route safe to retry. A request carrying an Idempotency-Key header is handled once per
+// caller and key: retries get the stored response back, and reusing the key for a different request is refused.
+// Requests without the header are handled as usual.
+func idempotent(svc services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			abortBadRequest(c, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		hash, err := requestHash(c.Request)
+		if err != nil {
+			abortBadRequest(c, "Missing / invalid required value")
+			return
+		}
+
+		record := entity.IdempotencyRecord{
+			Subject:     principalFrom(c).Subject,
+			Key:         key,
+			RequestHash: hash,
+		}
+
+		original, err := svc.Begin(c, record)
+		if err != nil {
+			httpHelper.ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+		if original != nil {
+			c.Header(idempotentReplayedHeader, "true")
+			c.Data(original.ResponseStatus, gin.MIMEJSON+"; charset=utf-8", original.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		//the outcome is stored even if the client went away, that is exactly when it will retry
+		ctx := context.WithoutCancel(c.Request.Context())
+		record.ResponseStatus = recorder.Status()
+		record.ResponseBody = recorder.body.Bytes()
+		if record.ResponseStatus >= http.StatusInternalServerError {
+			_ = svc.Release(ctx, record)
+			return
+		}
+		_ = svc.Complete(ctx, record)
+	}
+}
+
+func abortBadRequest(c *gin.Context, message string) {
+	httpHelper.Response(c,
+		false,
+		&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: message},
+		nil,
+		http.StatusBadRequest,
+	)
+	c.Abort()
+}
+
+// responseRecorder keeps a copy of the response body written by the handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestHash fingerprints the method, path and payload of r, the body is put back for the handler.
+// The payload is canonicalized so that retries differing only in JSON formatting or multipart boundary still match.
+func requestHash(r *http.Request) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	payload, err := canonicalBody(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func canonicalBody(contentType string, body []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body, nil
+	}
+
+	switch mediaType {
+	case gin.MIMEJSON:
+		//decoding into any and encoding back sorts object keys and drops insignificant whitespace
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	case gin.MIMEMultipartPOSTForm:
+		return canonicalMultipart(body, params["boundary"])
+	}
+
+	return body, nil
+}
+
+// canonicalMultipart lists the form parts sorted by name, files are represented by their file name and content hash
+func canonicalMultipart(body []byte, boundary string) ([]byte, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FileName() != "" {
+			sum := sha256.Sum256(content)
+			parts = append(parts, fmt.Sprintf("%s=file:%s:%s", part.FormName(), part.FileName(), hex.EncodeToString(sum[:])))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", part.FormName(), content))
+	}
+
+	sort.Strings(parts)
+	return []byte(strings.Join(parts, "\n")), nil
+}
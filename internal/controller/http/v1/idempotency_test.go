@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+)
+
+func Test_RequestHash(t *testing.T) {
+	t.Parallel()
+
+	hash := func(method, path, contentType, body string) string {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		h, err := requestHash(req)
+		assert.Nil(t, err)
+
+		restored := new(bytes.Buffer)
+		restored.ReadFrom(req.Body)
+		assert.Equal(t, body, restored.String())
+		return h
+	}
+
+	first := hash(http.MethodPost, "/v1/loans/1/investments", "application/json", `{"amount": 100, "currency": "IDR"}`)
+	assert.Equal(t, first, hash(http.MethodPost, "/v1/loans/1/investments", "application/json", "{\n\"currency\":\"IDR\",\"amount\":100}"))
+	assert.NotEqual(t, first, hash(http.MethodPost, "/v1/loans/1/investments", "application/json", `{"amount": 101, "currency": "IDR"}`))
+	assert.NotEqual(t, first, hash(http.MethodPost, "/v1/loans/2/investments", "application/json", `{"amount": 100, "currency": "IDR"}`))
+
+	multipartBody := func(boundary string) (string, string) {
+		buf := new(bytes.Buffer)
+		w := multipart.NewWriter(buf)
+		w.SetBoundary(boundary)
+		w.WriteField("status", "approved")
+		fw, _ := w.CreateFormFile("picture_proof", "visit.jpg")
+		fw.Write([]byte("jpeg bytes"))
+		w.Close()
+		return w.FormDataContentType(), buf.String()
+	}
+	contentType, body := multipartBody("boundary-one")
+	otherContentType, otherBody := multipartBody("boundary-two")
+	assert.Equal(t, hash(http.MethodPatch, "/v1/loans/1/status", contentType, body),
+		hash(http.MethodPatch, "/v1/loans/1/status", otherContentType, otherBody))
+}
+
+func Test_Idempotent(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mock.NewMockIdempotencyService(ctrl)
+
+	calls := 0
+	router := gin.New()
+	router.POST("/loans", func(c *gin.Context) {
+		c.Set("principal", &entity.Principal{Subject: "borrower-1", Role: entity.RoleBorrower})
+	}, idempotent(svc), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/loans", strings.NewReader(`{"principal_amount": 1000}`))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("without key", func(t *testing.T) {
+		w := send("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("first request is recorded", func(t *testing.T) {
+		svc.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(nil, nil)
+		svc.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, record entity.IdempotencyRecord) error {
+			assert.Equal(t, "borrower-1", record.Subject)
+			assert.Equal(t, "key-1", record.Key)
+			assert.Equal(t, http.StatusOK, record.ResponseStatus)
+			assert.JSONEq(t, `{"calls": 2}`, string(record.ResponseBody))
+			return nil
+		})
+
+		w := send("key-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("retry is replayed", func(t *testing.T) {
+		svc.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(&entity.IdempotencyRecord{
+			Status:         entity.IdempotencyStatusCompleted,
+			ResponseStatus: http.StatusOK,
+			ResponseBody:   []byte(`{"calls":2}`),
+		}, nil)
+
+		w := send("key-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get(idempotentReplayedHeader))
+		assert.JSONEq(t, `{"calls": 2}`, w.Body.String())
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("conflicting payload", func(t *testing.T) {
+		svc.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(nil, apperror.ErrIdempotencyKeyReused)
+
+		w := send("key-1")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		w := send(strings.Repeat("k", maxIdempotencyKeyLength+1))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+type investorRoutes struct {
+	loanService services.LoanService
+}
+
+func newInvestorRoutes(handler *gin.RouterGroup, svc services.LoanService) {
+	r := &investorRoutes{svc}
+
+	handler.GET("/investors/me/investments", authorize(actionListOwnInvestments), r.listInvestments) //investor portfolio
+	handler.GET("/investors/me/payouts", authorize(actionListOwnPayouts), r.listPayouts)             //repayments passed on to the investor
+}
+
+func (r *investorRoutes) listInvestments(c *gin.Context) {
+
+	investorID := c.GetString("investorID")
+
+	investments, err := r.loanService.ListInvestorInvestments(c, uuid.MustParse(investorID))
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		investments,
+		http.StatusOK,
+	)
+}
+
+func (r *investorRoutes) listPayouts(c *gin.Context) {
+
+	filter := entity.PayoutFilter{InvestorID: uuid.MustParse(c.GetString("investorID"))}
+
+	if loanID := c.Query("loan_id"); loanID != "" {
+		loanUUID, err := uuid.Parse(loanID)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid loan_id"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		filter.LoanID = loanUUID
+	}
+
+	payouts, err := r.loanService.ListInvestorPayouts(c, filter)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		payouts,
+		http.StatusOK,
+	)
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+type ledgerRoutes struct {
+	ledgerService services.LedgerService
+}
+
+func newLedgerRoutes(handler *gin.RouterGroup, svc services.LedgerService) {
+	r := &ledgerRoutes{svc}
+
+	handler.GET("/ledger/balances", authorize(actionReadLedger), r.listBalances)    //account balances
+	handler.GET("/ledger/integrity", authorize(actionReadLedger), r.checkIntegrity) //debits equal credits check
+}
+
+func (r *ledgerRoutes) listBalances(c *gin.Context) {
+
+	filter := entity.AccountFilter{
+		Type:     c.Query("account_type"),
+		Currency: c.Query("currency"),
+	}
+
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		ownerUUID, err := uuid.Parse(ownerID)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid owner_id"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		filter.OwnerID = ownerUUID
+	}
+
+	balances, err := r.ledgerService.ListBalances(c, filter)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		balances,
+		http.StatusOK,
+	)
+}
+
+func (r *ledgerRoutes) checkIntegrity(c *gin.Context) {
+
+	integrity, err := r.ledgerService.CheckIntegrity(c)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		integrity,
+		http.StatusOK,
+	)
+}
@@ -10,6 +10,7 @@ import (
 
 	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
 	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
 	"github.com/ferdikurniawan/loan-service/internal/services"
 )
 
@@ -17,14 +18,22 @@ type loanRoutes struct {
 	loanService services.LoanService
 }
 
-func newLoanRoutes(handler *gin.RouterGroup, svc services.LoanService) {
+func newLoanRoutes(handler *gin.RouterGroup, svc services.LoanService, idempotency services.IdempotencyService) {
 	r := &loanRoutes{svc}
 
-	handler.POST("/loans", r.submitLoan)                      //borrower submits a new Loan
-	handler.PATCH("/loans/:loan_id/status", r.updateLoan)     //update Loan status
-	handler.POST("/loans/:loan_id/investments", r.investLoan) //investor chip in
-	handler.POST("/loans/:loan_id/disburse", r.disburseLoan)  //disbursement
-	handler.GET("/loans/:loan_id", r.getLoan)                 //get loan detail
+	handler.POST("/loans", authorize(actionSubmitLoan), idempotent(idempotency), r.submitLoan)                              //borrower submits a new Loan
+	handler.PATCH("/loans/:loan_id/status", authorize(actionUpdateStatus), idempotent(idempotency), r.updateLoan)           //update Loan status
+	handler.POST("/loans/:loan_id/investments", authorize(actionInvestLoan), idempotent(idempotency), r.investLoan)         //investor chip in
+	handler.DELETE("/loans/:loan_id/investments/:investment_id", authorize(actionWithdrawInvestment), r.withdrawInvestment) //investor takes a pledge back
+	handler.POST("/loans/:loan_id/disburse", authorize(actionDisburseLoan), idempotent(idempotency), r.disburseLoan)        //disbursement
+	handler.GET("/loans/:loan_id", authorize(actionReadLoan), r.getLoan)                                                    //get loan detail
+	handler.GET("/loans", authorize(actionListLoans), r.listLoans)                                                          //list / search loans
+	handler.GET("/loans/:loan_id/investments", authorize(actionListLoanInvestments), r.listLoanInvestments)                 //investments of a loan
+	handler.GET("/loans/:loan_id/schedule", authorize(actionReadSchedule), r.getLoanSchedule)                               //repayment schedule
+	handler.POST("/loans/:loan_id/repayments", authorize(actionRepayLoan), idempotent(idempotency), r.repayLoan)            //staff record a repayment made by the borrower
+	handler.GET("/loans/:loan_id/agreement", authorize(actionReadAgreement), r.getAgreement)                                //download agreement letter
+	handler.POST("/loans/:loan_id/agreement", authorize(actionIssueAgreement), idempotent(idempotency), r.issueAgreement)   //regenerate agreement letter
+	handler.GET("/loans/:loan_id/history", authorize(actionReadHistory), r.getLoanHistory)                                  //who changed what and when
 }
 
 func (r *loanRoutes) submitLoan(c *gin.Context) {
@@ -44,12 +53,7 @@ func (r *loanRoutes) submitLoan(c *gin.Context) {
 
 	loan, err := r.loanService.CreateLoan(c, req)
 	if err != nil {
-		httpHelper.Response(c,
-			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: err.Error()},
-			nil,
-			http.StatusInternalServerError,
-		)
+		httpHelper.ErrorResponse(c, err)
 		return
 	}
 
@@ -64,20 +68,22 @@ func (r *loanRoutes) submitLoan(c *gin.Context) {
 func (r *loanRoutes) updateLoan(c *gin.Context) {
 
 	staffID := c.GetString("staffID")
-	loanID := c.Param("loan_id")
 
-	if loanID == "" {
+	//loanID must be UUID
+	loanID := c.Param("loan_id")
+	if _, err := uuid.Parse(loanID); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (loan ID)"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
 			http.StatusBadRequest,
 		)
 		return
 	}
 
+	//approval carries a picture proof so it is sent as multipart form, other status changes may stay JSON
 	var req entity.LoanUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBind(&req); err != nil {
 		httpHelper.Response(c,
 			false,
 			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
@@ -87,17 +93,27 @@ func (r *loanRoutes) updateLoan(c *gin.Context) {
 		return
 	}
 
+	if req.ApprovalDate != "" {
+		approvedAt, err := time.Parse("2006-01-02", req.ApprovalDate)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Approval date is invalid"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		req.ApprovedAt = approvedAt
+	}
+
+	//the picture proof, when given, is kept in the document store by the service
 	req.LoanID = loanID
 	req.StaffID = staffID
 
 	err := r.loanService.UpdateLoan(c, req)
 	if err != nil {
-		httpHelper.Response(c,
-			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: err.Error()},
-			nil,
-			http.StatusInternalServerError,
-		)
+		httpHelper.ErrorResponse(c, err)
 		return
 	}
 
@@ -112,12 +128,13 @@ func (r *loanRoutes) updateLoan(c *gin.Context) {
 func (r *loanRoutes) investLoan(c *gin.Context) {
 
 	investorID := c.GetString("investorID")
-	loanID := c.Param("loan_id")
 
-	if loanID == "" {
+	//loanID must be UUID
+	loanID := c.Param("loan_id")
+	if _, err := uuid.Parse(loanID); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (loan ID)"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
 			http.StatusBadRequest,
 		)
@@ -140,33 +157,75 @@ func (r *loanRoutes) investLoan(c *gin.Context) {
 
 	err := r.loanService.InvestLoan(c, req)
 	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		nil,
+		http.StatusOK,
+	)
+
+}
+
+func (r *loanRoutes) withdrawInvestment(c *gin.Context) {
+
+	investorID := c.GetString("investorID")
+
+	//loanID and investmentID must be UUID
+	loanID := c.Param("loan_id")
+	investmentID := c.Param("investment_id")
+	if _, err := uuid.Parse(loanID); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: err.Error()},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
-			http.StatusInternalServerError,
+			http.StatusBadRequest,
 		)
 		return
 	}
+	if _, err := uuid.Parse(investmentID); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (investment ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req := entity.LoanWithdrawRequest{
+		LoanID:       loanID,
+		InvestmentID: investmentID,
+		InvestorID:   uuid.MustParse(investorID),
+	}
+
+	withdrawal, err := r.loanService.WithdrawInvestment(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
 
 	httpHelper.Response(c,
 		true,
 		nil,
-		nil,
+		withdrawal,
 		http.StatusOK,
 	)
-
 }
 
 func (r *loanRoutes) disburseLoan(c *gin.Context) {
 
 	staffID := c.GetString("staffID")
-	loanID := c.Param("loan_id")
 
-	if loanID == "" {
+	//loanID must be UUID
+	loanID := c.Param("loan_id")
+	if _, err := uuid.Parse(loanID); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (loan ID)"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
 			http.StatusBadRequest,
 		)
@@ -208,81 +267,284 @@ func (r *loanRoutes) disburseLoan(c *gin.Context) {
 		return
 	}
 
-	fileHeader, err := c.FormFile("agreement_file")
+	//the signed agreement, bound as req.LoanAgreementDocs, is optional: without it the loan is disbursed with the
+	//agreement letter generated by the service
+	req.LoanID = loanID
+	req.DisburseAt = disburseAt
+	req.StaffID = staffID
+
+	err = r.loanService.DisburseLoan(c, req)
 	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		nil,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) repayLoan(c *gin.Context) {
+
+	staffID := c.GetString("staffID")
+
+	//loanID must be UUID
+	loanID := c.Param("loan_id")
+	if _, err := uuid.Parse(loanID); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing agreement file"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
 			http.StatusBadRequest,
 		)
 		return
 	}
 
-	file, err := fileHeader.Open()
-	if err != nil {
+	var req entity.LoanRepaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Failed to open agreement file"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
 			nil,
 			http.StatusBadRequest,
 		)
 		return
 	}
-	defer file.Close()
 
-	// Save file to disk first (based on requirement, this should be uploaded to S3 but this is for demo purpose)
-	savePath := fmt.Sprintf("./uploads/agreements/%s_%s", loanID, fileHeader.Filename)
-	if err := c.SaveUploadedFile(fileHeader, savePath); err != nil {
+	if req.PaidOn != "" {
+		paidAt, err := time.Parse("2006-01-02", req.PaidOn)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Payment date is invalid"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		req.PaidAt = paidAt
+	}
+
+	req.LoanID = loanID
+	req.StaffID = staffID
+
+	repayment, err := r.loanService.RepayLoan(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		repayment,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) getLoan(c *gin.Context) {
+
+	loanID := c.Param("loan_id")
+
+	if loanID == "" {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: "Failed to store agreement file"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (loan ID)"},
 			nil,
-			http.StatusInternalServerError,
+			http.StatusBadRequest,
 		)
 		return
 	}
 
-	req.LoanID = loanID
-	req.DisburseAt = disburseAt
-	req.StaffID = staffID
-	req.AgreementLetterLink = savePath
+	//loanID must be UUID
+	loanUUID, err := uuid.Parse(loanID)
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
 
-	err = r.loanService.DisburseLoan(c, req)
+	loan, err := r.loanService.GetLoanByID(c, loanUUID)
 	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	if !canViewLoan(principalFrom(c), loan) {
+		httpHelper.ErrorResponse(c, apperror.ErrForbidden)
+		return
+	}
+	scopeLoanDetail(principalFrom(c), loan)
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		loan,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) listLoans(c *gin.Context) {
+
+	var req entity.LoanListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: err.Error()},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
 			nil,
-			http.StatusInternalServerError,
+			http.StatusBadRequest,
 		)
 		return
 	}
 
+	//created_from & created_to are dates, created_to is inclusive so the upper bound is the next day
+	if req.CreatedFrom != "" {
+		createdFrom, err := time.Parse("2006-01-02", req.CreatedFrom)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "created_from is invalid"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		req.CreatedAfter = createdFrom
+	}
+	if req.CreatedTo != "" {
+		createdTo, err := time.Parse("2006-01-02", req.CreatedTo)
+		if err != nil {
+			httpHelper.Response(c,
+				false,
+				&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "created_to is invalid"},
+				nil,
+				http.StatusBadRequest,
+			)
+			return
+		}
+		req.CreatedBefore = createdTo.AddDate(0, 0, 1)
+	}
+
+	if err := scopeLoanList(principalFrom(c), &req); err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	page, err := r.loanService.ListLoans(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
 	httpHelper.Response(c,
 		true,
 		nil,
+		page,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) listLoanInvestments(c *gin.Context) {
+
+	//loanID must be UUID
+	loanUUID, err := uuid.Parse(c.Param("loan_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	investments, err := r.loanService.ListLoanInvestments(c, loanUUID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
 		nil,
+		investments,
 		http.StatusOK,
 	)
 }
 
-func (r *loanRoutes) getLoan(c *gin.Context) {
+func (r *loanRoutes) getLoanSchedule(c *gin.Context) {
 
-	loanID := c.Param("loan_id")
+	//loanID must be UUID
+	loanUUID, err := uuid.Parse(c.Param("loan_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
 
-	if loanID == "" {
+	schedule, err := r.loanService.GetLoanSchedule(c, loanUUID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	//same visibility as the loan itself
+	if !canViewLoan(principalFrom(c), &entity.Loan{BorrowerID: schedule.BorrowerID, Status: schedule.LoanStatus}) {
+		httpHelper.ErrorResponse(c, apperror.ErrForbidden)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		schedule,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) getLoanHistory(c *gin.Context) {
+
+	//loanID must be UUID
+	loanUUID, err := uuid.Parse(c.Param("loan_id"))
+	if err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value (loan ID)"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
 			http.StatusBadRequest,
 		)
 		return
 	}
 
+	history, err := r.loanService.GetLoanHistory(c, loanUUID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		history,
+		http.StatusOK,
+	)
+}
+
+func (r *loanRoutes) getAgreement(c *gin.Context) {
+
 	//loanID must be UUID
-	loanUUID, err := uuid.Parse(loanID)
+	loanUUID, err := uuid.Parse(c.Param("loan_id"))
 	if err != nil {
 		httpHelper.Response(c,
 			false,
@@ -293,21 +555,64 @@ func (r *loanRoutes) getLoan(c *gin.Context) {
 		return
 	}
 
+	format := c.DefaultQuery("format", entity.AgreementFormatPDF)
+	if format != entity.AgreementFormatPDF && format != entity.AgreementFormatHTML {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "format must be pdf or html"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
 	loan, err := r.loanService.GetLoanByID(c, loanUUID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	if !canViewAgreement(principalFrom(c), loan) {
+		httpHelper.ErrorResponse(c, apperror.ErrForbidden)
+		return
+	}
+
+	agreement, err := r.loanService.GetAgreement(c, loanUUID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	contentType, content := agreement.Content(format)
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="agreement_%s_%s.%s"`, loanUUID, agreement.TemplateVersion, format))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func (r *loanRoutes) issueAgreement(c *gin.Context) {
+
+	//loanID must be UUID
+	loanUUID, err := uuid.Parse(c.Param("loan_id"))
 	if err != nil {
 		httpHelper.Response(c,
 			false,
-			&entity.ErrorResponse{Code: 500, Type: "server_error", Message: "internal server error"},
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
 			nil,
-			http.StatusInternalServerError,
+			http.StatusBadRequest,
 		)
 		return
 	}
 
+	staffID := uuid.MustParse(c.GetString("staffID"))
+	agreement, err := r.loanService.IssueAgreement(c, loanUUID, entity.StaffActor(staffID))
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
 	httpHelper.Response(c,
 		true,
 		nil,
-		loan,
+		agreement,
 		http.StatusOK,
 	)
 }
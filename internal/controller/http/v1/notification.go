@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+type notificationRoutes struct {
+	notificationService services.NotificationService
+}
+
+func newNotificationRoutes(handler *gin.RouterGroup, svc services.NotificationService) {
+	r := &notificationRoutes{svc}
+
+	handler.GET("/investors/me/contact", authorize(actionReadOwnContact), r.getOwnContact)                    //where the investor's e-mails go
+	handler.PUT("/investors/me/contact", authorize(actionUpdateOwnContact), r.updateOwnContact)               //change it
+	handler.GET("/loans/:loan_id/notifications", authorize(actionListNotifications), r.listLoanNotifications) //delivery state of the loan's e-mails
+}
+
+func (r *notificationRoutes) getOwnContact(c *gin.Context) {
+
+	contact, err := r.notificationService.GetContact(c, uuid.MustParse(c.GetString("investorID")))
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		contact,
+		http.StatusOK,
+	)
+}
+
+func (r *notificationRoutes) updateOwnContact(c *gin.Context) {
+
+	var req entity.ContactUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req.InvestorID = uuid.MustParse(c.GetString("investorID"))
+
+	contact, err := r.notificationService.SaveContact(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		contact,
+		http.StatusOK,
+	)
+}
+
+func (r *notificationRoutes) listLoanNotifications(c *gin.Context) {
+
+	//loanID must be UUID
+	loanID, err := uuid.Parse(c.Param("loan_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (loan ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	notifications, err := r.notificationService.ListLoanNotifications(c, loanID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		notifications,
+		http.StatusOK,
+	)
+}
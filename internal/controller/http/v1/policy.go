@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+type action string
+
+const (
+	actionSubmitLoan         action = "loan:submit"
+	actionUpdateStatus       action = "loan:update_status"
+	actionInvestLoan         action = "loan:invest"
+	actionWithdrawInvestment action = "loan:withdraw_investment"
+	actionDisburseLoan       action = "loan:disburse"
+	actionReadLoan           action = "loan:read"
+	actionListLoans          action = "loan:list"
+	actionReadSchedule       action = "loan:read_schedule"
+	actionRepayLoan          action = "loan:repay"
+	actionReadAgreement      action = "loan:read_agreement"
+	actionIssueAgreement     action = "loan:issue_agreement"
+	actionReadHistory        action = "loan:read_history"
+
+	actionListLoanInvestments action = "loan:list_investments"
+	actionListOwnInvestments  action = "investor:list_investments"
+	actionListOwnPayouts      action = "investor:list_payouts"
+
+	actionReadLedger action = "ledger:read"
+
+	actionReadOwnWallet action = "investor:read_wallet"
+	actionReadWallet    action = "wallet:read"
+	actionTopUpWallet   action = "wallet:top_up"
+
+	actionReadOwnContact    action = "investor:read_contact"
+	actionUpdateOwnContact  action = "investor:update_contact"
+	actionListNotifications action = "loan:list_notifications"
+
+	actionManageWebhooks action = "webhook:manage"
+	actionListDeliveries action = "webhook:list_deliveries"
+	actionRetryDelivery  action = "webhook:retry_delivery"
+)
+
+// policy lists the roles allowed to perform each action, an action missing from the table is denied to everyone
+var policy = map[action][]string{
+	actionSubmitLoan:         {entity.RoleBorrower},
+	actionUpdateStatus:       {entity.RoleStaff},
+	actionInvestLoan:         {entity.RoleInvestor},
+	actionWithdrawInvestment: {entity.RoleInvestor},
+	actionDisburseLoan:       {entity.RoleStaff},
+	actionReadLoan:           {entity.RoleStaff, entity.RoleBorrower, entity.RoleInvestor},
+	actionListLoans:          {entity.RoleStaff, entity.RoleBorrower, entity.RoleInvestor},
+	actionReadSchedule:       {entity.RoleStaff, entity.RoleBorrower, entity.RoleInvestor},
+	actionRepayLoan:          {entity.RoleStaff},
+	actionReadAgreement:      {entity.RoleStaff, entity.RoleBorrower, entity.RoleInvestor},
+	actionIssueAgreement:     {entity.RoleStaff},
+	actionReadHistory:        {entity.RoleStaff},
+
+	actionListLoanInvestments: {entity.RoleStaff},
+	actionListOwnInvestments:  {entity.RoleInvestor},
+	actionListOwnPayouts:      {entity.RoleInvestor},
+
+	actionReadLedger: {entity.RoleStaff},
+
+	actionReadOwnWallet: {entity.RoleInvestor},
+	actionReadWallet:    {entity.RoleStaff},
+	actionTopUpWallet:   {entity.RoleStaff},
+
+	actionReadOwnContact:    {entity.RoleInvestor},
+	actionUpdateOwnContact:  {entity.RoleInvestor},
+	actionListNotifications: {entity.RoleStaff},
+
+	actionManageWebhooks: {entity.RoleStaff},
+	actionListDeliveries: {entity.RoleStaff},
+	actionRetryDelivery:  {entity.RoleStaff},
+}
+
+// investorStatuses are the statuses in which investors can see a loan, i.e. once it has been approved
+var investorStatuses = []string{entity.LoanStatusApproved, entity.LoanStatusInvested, entity.LoanStatusDisbursed,
+	entity.LoanStatusRepaying, entity.LoanStatusClosed}
+
+var investorVisibleStatuses = map[string]bool{}
+
+func init() {
+	for _, status := range investorStatuses {
+		investorVisibleStatuses[status] = true
+	}
+}
+
+func allowed(role string, act action) bool {
+	for _, r := range policy[act] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// canViewLoan narrows actionReadLoan down to a single loan: borrowers only see their own loans,
+// investors only see approved ones
+func canViewLoan(principal *entity.Principal, loan *entity.Loan) bool {
+	switch principal.Role {
+	case entity.RoleStaff:
+		return true
+	case entity.RoleBorrower:
+		return loan.BorrowerID.String() == principal.Subject
+	case entity.RoleInvestor:
+		return investorVisibleStatuses[loan.Status]
+	}
+	return false
+}
+
+// canViewAgreement narrows actionReadAgreement down to the parties of the loan: its borrower and its investors
+func canViewAgreement(principal *entity.Principal, loan *entity.Loan) bool {
+	switch principal.Role {
+	case entity.RoleStaff:
+		return true
+	case entity.RoleBorrower:
+		return loan.BorrowerID.String() == principal.Subject
+	case entity.RoleInvestor:
+		for _, ret := range loan.InvestorReturns {
+			if ret.InvestorID.String() == principal.Subject {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopeLoanDetail hides other investors' returns: investors only see their own, borrowers see none
+func scopeLoanDetail(principal *entity.Principal, loan *entity.Loan) {
+	switch principal.Role {
+	case entity.RoleStaff:
+		return
+	case entity.RoleInvestor:
+		own := []entity.InvestorReturn{}
+		for _, ret := range loan.InvestorReturns {
+			if ret.InvestorID.String() == principal.Subject {
+				own = append(own, ret)
+			}
+		}
+		loan.InvestorReturns = own
+	default:
+		loan.InvestorReturns = nil
+	}
+}
+
+// scopeLoanList applies canViewLoan to a listing: borrowers are pinned to their own loans and
+// investors to approved ones, asking for anything beyond that is forbidden
+func scopeLoanList(principal *entity.Principal, req *entity.LoanListRequest) error {
+	switch principal.Role {
+	case entity.RoleStaff:
+		return nil
+	case entity.RoleBorrower:
+		if req.BorrowerID != "" && req.BorrowerID != principal.Subject {
+			return apperror.ErrForbidden
+		}
+		req.BorrowerID = principal.Subject
+		return nil
+	case entity.RoleInvestor:
+		if len(req.Statuses) == 0 {
+			req.Statuses = investorStatuses
+			return nil
+		}
+		for _, raw := range req.Statuses {
+			for _, status := range strings.Split(raw, ",") {
+				if !investorVisibleStatuses[status] {
+					return apperror.ErrForbidden
+				}
+			}
+		}
+		return nil
+	}
+	return apperror.ErrForbidden
+}
+
+// authorize aborts with 403 unless the authenticated caller's role may perform act
+func authorize(act action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowed(c.GetString("role"), act) {
+			httpHelper.ErrorResponse(c, apperror.ErrForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// principalFrom returns the caller set by AuthMiddleware
+func principalFrom(c *gin.Context) *entity.Principal {
+	principal, _ := c.MustGet("principal").(*entity.Principal)
+	return principal
+}
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+func Test_Policy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		role    string
+		act     action
+		allowed bool
+	}{
+		{entity.RoleBorrower, actionSubmitLoan, true},
+		{entity.RoleBorrower, actionUpdateStatus, false},
+		{entity.RoleBorrower, actionInvestLoan, false},
+		{entity.RoleBorrower, actionDisburseLoan, false},
+		{entity.RoleBorrower, actionReadLoan, true},
+
+		{entity.RoleInvestor, actionSubmitLoan, false},
+		{entity.RoleInvestor, actionUpdateStatus, false},
+		{entity.RoleInvestor, actionInvestLoan, true},
+		{entity.RoleInvestor, actionDisburseLoan, false},
+		{entity.RoleInvestor, actionReadLoan, true},
+
+		{entity.RoleStaff, actionSubmitLoan, false},
+		{entity.RoleStaff, actionUpdateStatus, true},
+		{entity.RoleStaff, actionInvestLoan, false},
+		{entity.RoleStaff, actionDisburseLoan, true},
+		{entity.RoleStaff, actionReadLoan, true},
+
+		{entity.RoleInvestor, actionWithdrawInvestment, true},
+		{entity.RoleStaff, actionWithdrawInvestment, false},
+		{entity.RoleInvestor, actionReadOwnWallet, true},
+		{entity.RoleInvestor, actionTopUpWallet, false},
+		{entity.RoleStaff, actionTopUpWallet, true},
+		{entity.RoleStaff, actionReadWallet, true},
+		{entity.RoleBorrower, actionReadWallet, false},
+		{entity.RoleBorrower, actionReadAgreement, true},
+		{entity.RoleInvestor, actionIssueAgreement, false},
+		{entity.RoleStaff, actionIssueAgreement, true},
+		{entity.RoleStaff, actionReadHistory, true},
+		{entity.RoleBorrower, actionReadHistory, false},
+		{entity.RoleInvestor, actionUpdateOwnContact, true},
+		{entity.RoleStaff, actionUpdateOwnContact, false},
+		{entity.RoleInvestor, actionListNotifications, false},
+		{entity.RoleStaff, actionListNotifications, true},
+		{entity.RoleStaff, actionManageWebhooks, true},
+		{entity.RoleInvestor, actionManageWebhooks, false},
+		{entity.RoleBorrower, actionListDeliveries, false},
+
+		{"", actionReadLoan, false},
+		{entity.RoleStaff, action("loan:unknown"), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, allowed(tt.role, tt.act), "%s %s", tt.role, tt.act)
+	}
+}
+
+func Test_CanViewLoan(t *testing.T) {
+	t.Parallel()
+
+	borrowerID := uuid.MustParse("d149aaa5-e7e8-4820-93a0-e278dcde447a")
+	proposed := &entity.Loan{BorrowerID: borrowerID, Status: entity.LoanStatusProposed}
+	approved := &entity.Loan{BorrowerID: borrowerID, Status: entity.LoanStatusApproved}
+
+	staff := &entity.Principal{Subject: uuid.NewString(), Role: entity.RoleStaff}
+	owner := &entity.Principal{Subject: borrowerID.String(), Role: entity.RoleBorrower}
+	otherBorrower := &entity.Principal{Subject: uuid.NewString(), Role: entity.RoleBorrower}
+	investor := &entity.Principal{Subject: uuid.NewString(), Role: entity.RoleInvestor}
+
+	assert.True(t, canViewLoan(staff, proposed))
+	assert.True(t, canViewLoan(owner, proposed))
+	assert.False(t, canViewLoan(otherBorrower, proposed))
+	assert.False(t, canViewLoan(investor, proposed))
+	assert.True(t, canViewLoan(investor, approved))
+}
+
+func Test_CanViewAgreement(t *testing.T) {
+	t.Parallel()
+
+	borrowerID := uuid.MustParse("d149aaa5-e7e8-4820-93a0-e278dcde447a")
+	investorID := uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886")
+	loan := &entity.Loan{
+		BorrowerID:      borrowerID,
+		Status:          entity.LoanStatusInvested,
+		InvestorReturns: []entity.InvestorReturn{{InvestorID: investorID}},
+	}
+
+	assert.True(t, canViewAgreement(&entity.Principal{Subject: uuid.NewString(), Role: entity.RoleStaff}, loan))
+	assert.True(t, canViewAgreement(&entity.Principal{Subject: borrowerID.String(), Role: entity.RoleBorrower}, loan))
+	assert.False(t, canViewAgreement(&entity.Principal{Subject: uuid.NewString(), Role: entity.RoleBorrower}, loan))
+	assert.True(t, canViewAgreement(&entity.Principal{Subject: investorID.String(), Role: entity.RoleInvestor}, loan))
+	assert.False(t, canViewAgreement(&entity.Principal{Subject: uuid.NewString(), Role: entity.RoleInvestor}, loan))
+}
+
+func Test_Authorize(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	serve := func(role string) int {
+		handler := gin.New()
+		handler.POST("/loans/:loan_id/disburse", func(c *gin.Context) {
+			c.Set("role", role)
+		}, authorize(actionDisburseLoan), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/loans/2badced4-3fa0-4a7e-8dcf-7c8031f0e704/disburse", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(entity.RoleStaff))
+	assert.Equal(t, http.StatusForbidden, serve(entity.RoleBorrower))
+	assert.Equal(t, http.StatusForbidden, serve(entity.RoleInvestor))
+}
+
+func Test_ScopeLoanList(t *testing.T) {
+	t.Parallel()
+
+	borrower := &entity.Principal{Subject: "d149aaa5-e7e8-4820-93a0-e278dcde447a", Role: entity.RoleBorrower}
+	investor := &entity.Principal{Subject: uuid.NewString(), Role: entity.RoleInvestor}
+	staff := &entity.Principal{Subject: uuid.NewString(), Role: entity.RoleStaff}
+
+	req := entity.LoanListRequest{}
+	assert.Nil(t, scopeLoanList(borrower, &req))
+	assert.Equal(t, borrower.Subject, req.BorrowerID)
+
+	req = entity.LoanListRequest{BorrowerID: uuid.NewString()}
+	assert.NotNil(t, scopeLoanList(borrower, &req))
+
+	req = entity.LoanListRequest{}
+	assert.Nil(t, scopeLoanList(investor, &req))
+	assert.Equal(t, []string{"approved", "invested", "disbursed", "repaying", "closed"}, req.Statuses)
+
+	req = entity.LoanListRequest{Statuses: []string{"approved,proposed"}}
+	assert.NotNil(t, scopeLoanList(investor, &req))
+
+	req = entity.LoanListRequest{Statuses: []string{"proposed"}}
+	assert.Nil(t, scopeLoanList(staff, &req))
+	assert.Equal(t, []string{"proposed"}, req.Statuses)
+}
@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/pkg/requestid"
+)
+
+// RequestIDMiddleware gives every request an ID, the one sent by the client in X-Request-ID when it is usable or a
+// new one otherwise. The ID is echoed back in the response header and carried by the request context, where the
+// loan history picks it up.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = uuid.NewString()
+		}
+
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+	}
+}
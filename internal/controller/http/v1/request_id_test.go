@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/pkg/requestid"
+)
+
+func Test_RequestIDMiddleware(t *testing.T) {
+	t.Parallel()
+
+	//as in the app, the handlers hand the gin context to the services as their context
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.GET("/loans", RequestIDMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c))
+	})
+
+	send := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/loans", nil)
+		if id != "" {
+			req.Header.Set(requestid.Header, id)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("the client's request ID is used", func(t *testing.T) {
+		w := send("req-2025-06-01-0001")
+		assert.Equal(t, "req-2025-06-01-0001", w.Header().Get(requestid.Header))
+		assert.Equal(t, "req-2025-06-01-0001", w.Body.String())
+	})
+
+	t.Run("a request ID is generated when missing or unusable", func(t *testing.T) {
+		for _, id := range []string{"", "has spaces", strings.Repeat("a", requestid.MaxLength+1)} {
+			w := send(id)
+			generated := w.Header().Get(requestid.Header)
+			_, err := uuid.Parse(generated)
+			assert.Nil(t, err, id)
+			assert.Equal(t, generated, w.Body.String())
+		}
+	})
+}
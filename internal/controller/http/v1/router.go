@@ -1,8 +1,6 @@
 package v1
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/ferdikurniawan/loan-service/config"
@@ -13,7 +11,15 @@ import (
 type Services struct {
 	Cfg *config.Config
 
-	LoanService services.LoanService
+	Authenticator Authenticator
+	LoanService   services.LoanService
+	LedgerService services.LedgerService
+	WalletService services.WalletService
+
+	NotificationService services.NotificationService
+	WebhookService      services.WebhookService
+
+	IdempotencyService services.IdempotencyService
 }
 
 func (s Services) Initialized() error {
@@ -29,36 +35,13 @@ func NewRouter(handler *gin.Engine, s Services) {
 
 	// Routers
 	h := handler.Group("v1")
-	h.Use(DummyAuthMiddleware())
+	h.Use(RequestIDMiddleware(), AuthMiddleware(s.Authenticator))
 	{
-		newLoanRoutes(h, s.LoanService)
-	}
-}
-
-// Dummy auth to get the app working, we can differentiate actor based on Header
-func DummyAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		staffID := c.GetHeader("X-Staff-ID")
-		borrowerID := c.GetHeader("X-Borrower-ID")
-		investorID := c.GetHeader("X-Investor-ID")
-
-		if staffID == "" && borrowerID == "" && investorID == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Missing X-Staff-ID or X-Borrower-ID or X-Investor-ID",
-			})
-			return
-		}
-
-		if staffID != "" {
-			c.Set("staffID", staffID)
-		}
-		if borrowerID != "" {
-			c.Set("borrowerID", borrowerID)
-		}
-		if investorID != "" {
-			c.Set("investorID", investorID)
-		}
-
-		c.Next()
+		newLoanRoutes(h, s.LoanService, s.IdempotencyService)
+		newInvestorRoutes(h, s.LoanService)
+		newLedgerRoutes(h, s.LedgerService)
+		newWalletRoutes(h, s.WalletService)
+		newNotificationRoutes(h, s.NotificationService)
+		newWebhookRoutes(h, s.WebhookService)
 	}
 }
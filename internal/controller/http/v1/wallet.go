@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+type walletRoutes struct {
+	walletService services.WalletService
+}
+
+func newWalletRoutes(handler *gin.RouterGroup, svc services.WalletService) {
+	r := &walletRoutes{svc}
+
+	handler.GET("/investors/me/wallet", authorize(actionReadOwnWallet), r.getOwnWallet)                 //investor balance
+	handler.GET("/investors/:investor_id/wallet", authorize(actionReadWallet), r.getWallet)             //any investor's balance
+	handler.POST("/investors/:investor_id/wallet/top-ups", authorize(actionTopUpWallet), r.topUpWallet) //funds received for an investor
+}
+
+func (r *walletRoutes) getOwnWallet(c *gin.Context) {
+	r.listWallets(c, uuid.MustParse(c.GetString("investorID")))
+}
+
+func (r *walletRoutes) getWallet(c *gin.Context) {
+
+	//investorID must be UUID
+	investorID, err := uuid.Parse(c.Param("investor_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (investor ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	r.listWallets(c, investorID)
+}
+
+func (r *walletRoutes) listWallets(c *gin.Context, investorID uuid.UUID) {
+
+	wallets, err := r.walletService.ListWallets(c, investorID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		wallets,
+		http.StatusOK,
+	)
+}
+
+func (r *walletRoutes) topUpWallet(c *gin.Context) {
+
+	staffID := c.GetString("staffID")
+
+	//investorID must be UUID
+	investorID, err := uuid.Parse(c.Param("investor_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (investor ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var req entity.WalletTopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req.InvestorID = investorID
+	req.StaffID = staffID
+
+	topUp, err := r.walletService.TopUp(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		topUp,
+		http.StatusOK,
+	)
+}
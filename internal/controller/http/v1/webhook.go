@@ -0,0 +1,250 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	httpHelper "github.com/ferdikurniawan/loan-service/internal/controller/http"
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services"
+)
+
+type webhookRoutes struct {
+	webhookService services.WebhookService
+}
+
+func newWebhookRoutes(handler *gin.RouterGroup, svc services.WebhookService) {
+	r := &webhookRoutes{svc}
+
+	handler.POST("/webhooks", authorize(actionManageWebhooks), r.createWebhook)                                          //subscribe a partner endpoint
+	handler.GET("/webhooks", authorize(actionManageWebhooks), r.listWebhooks)                                            //every subscription
+	handler.GET("/webhooks/:webhook_id", authorize(actionManageWebhooks), r.getWebhook)                                  //one subscription
+	handler.PATCH("/webhooks/:webhook_id", authorize(actionManageWebhooks), r.updateWebhook)                             //change url / event types, pause or resume
+	handler.DELETE("/webhooks/:webhook_id", authorize(actionManageWebhooks), r.deleteWebhook)                            //unsubscribe, the delivery log goes with it
+	handler.GET("/webhooks/:webhook_id/deliveries", authorize(actionListDeliveries), r.listDeliveries)                   //delivery log
+	handler.POST("/webhooks/:webhook_id/deliveries/:delivery_id/retry", authorize(actionRetryDelivery), r.retryDelivery) //send a dead delivery again
+}
+
+func (r *webhookRoutes) createWebhook(c *gin.Context) {
+
+	var req entity.WebhookCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req.StaffID = uuid.MustParse(c.GetString("staffID"))
+
+	webhook, err := r.webhookService.CreateWebhook(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		webhook,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) listWebhooks(c *gin.Context) {
+
+	webhooks, err := r.webhookService.ListWebhooks(c)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		webhooks,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) getWebhook(c *gin.Context) {
+
+	//webhookID must be UUID
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (webhook ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	webhook, err := r.webhookService.GetWebhook(c, webhookID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		webhook,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) updateWebhook(c *gin.Context) {
+
+	//webhookID must be UUID
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (webhook ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var req entity.WebhookUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req.ID = webhookID
+
+	webhook, err := r.webhookService.UpdateWebhook(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		webhook,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) deleteWebhook(c *gin.Context) {
+
+	//webhookID must be UUID
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (webhook ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if err := r.webhookService.DeleteWebhook(c, webhookID); err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		nil,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) listDeliveries(c *gin.Context) {
+
+	//webhookID must be UUID
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (webhook ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var req entity.WebhookDeliveryFilter
+	if err := c.ShouldBindQuery(&req); err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "Missing / invalid required value"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	req.WebhookID = webhookID
+
+	deliveries, err := r.webhookService.ListDeliveries(c, req)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		deliveries,
+		http.StatusOK,
+	)
+}
+
+func (r *webhookRoutes) retryDelivery(c *gin.Context) {
+
+	//webhookID must be UUID
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (webhook ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	//deliveryID must be UUID
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		httpHelper.Response(c,
+			false,
+			&entity.ErrorResponse{Code: 400, Type: "bad_request", Message: "invalid required value (delivery ID)"},
+			nil,
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	delivery, err := r.webhookService.RetryDelivery(c, webhookID, deliveryID)
+	if err != nil {
+		httpHelper.ErrorResponse(c, err)
+		return
+	}
+
+	httpHelper.Response(c,
+		true,
+		nil,
+		delivery,
+		http.StatusOK,
+	)
+}
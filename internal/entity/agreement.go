@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AgreementFormatPDF  = "pdf"
+	AgreementFormatHTML = "html"
+)
+
+// LoanAgreement is an agreement letter generated for a loan from a versioned template, in both formats
+type LoanAgreement struct {
+	ID              uuid.UUID `json:"agreement_id"`
+	LoanID          uuid.UUID `json:"loan_id"`
+	TemplateVersion string    `json:"template_version"`
+	HTML            []byte    `json:"-"`
+	PDF             []byte    `json:"-"`
+	Link            string    `json:"link"`
+	DocumentKey     string    `json:"-"` //copy of the PDF letter in the document store
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Content returns the letter in format, pdf when the format is unknown
+func (a *LoanAgreement) Content(format string) (contentType string, content []byte) {
+	if format == AgreementFormatHTML {
+		return "text/html; charset=utf-8", a.HTML
+	}
+	return "application/pdf", a.PDF
+}
@@ -0,0 +1,13 @@
+package entity
+
+const (
+	RoleStaff    = "staff"
+	RoleBorrower = "borrower"
+	RoleInvestor = "investor"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string `json:"sub"`
+	Role    string `json:"role"`
+}
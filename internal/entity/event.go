@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoanEventVersion is the version of the loan.* event payloads. Fields may be added within a version,
+// renaming or removing one is a new version.
+const LoanEventVersion = 1
+
+// LoanEventType names the event published when a loan enters status, e.g. loan.approved
+func LoanEventType(status string) string {
+	return "loan." + status
+}
+
+// LoanEventTypes are the loan.* events published, i.e. the ones webhooks can subscribe to
+var LoanEventTypes = []string{
+	LoanEventType(LoanStatusApproved),
+	LoanEventType(LoanStatusRejected),
+	LoanEventType(LoanStatusCancelled),
+	LoanEventType(LoanStatusExpired),
+	LoanEventType(LoanStatusInvested),
+	LoanEventType(LoanStatusDisbursed),
+}
+
+// OutboxEvent is a domain event written to the outbox in the transaction of the change it describes,
+// and published afterwards by the relay. It is also the JSON envelope consumers receive.
+type OutboxEvent struct {
+	ID          uuid.UUID       `json:"event_id"`
+	Type        string          `json:"event_type"`
+	Version     int             `json:"event_version"`
+	AggregateID uuid.UUID       `json:"aggregate_id"` //the loan, events of one loan are published in order
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+
+	Attempts int `json:"-"` //failed publications so far
+}
+
+// LoanStatusChanged is the payload of the loan.* events
+type LoanStatusChanged struct {
+	LoanID          uuid.UUID  `json:"loan_id"`
+	BorrowerID      uuid.UUID  `json:"borrower_id"`
+	PrincipalAmount Money      `json:"principal_amount"`
+	From            string     `json:"previous_status"`
+	To              string     `json:"status"`
+	Reason          string     `json:"reason,omitempty"`
+	ChangedBy       *uuid.UUID `json:"changed_by,omitempty"`       //staff behind the change, none for changes made by the system or by investments
+	FundingDeadline *time.Time `json:"funding_deadline,omitempty"` //set on loan.approved
+	DisburseAt      *time.Time `json:"disburse_at,omitempty"`      //set on loan.disbursed
+}
+
+// NewLoanStatusEvent wraps a status change in its event envelope
+func NewLoanStatusEvent(change LoanStatusChanged, occurredAt time.Time) (OutboxEvent, error) {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return OutboxEvent{
+		ID:          uuid.New(),
+		Type:        LoanEventType(change.To),
+		Version:     LoanEventVersion,
+		AggregateID: change.LoanID,
+		OccurredAt:  occurredAt,
+		Payload:     payload,
+	}, nil
+}
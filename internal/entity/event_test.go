@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewLoanStatusEvent(t *testing.T) {
+	t.Parallel()
+
+	loanID := uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b")
+	borrowerID := uuid.MustParse("d149aaa5-e7e8-4820-93a0-e278dcde447a")
+	occurredAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	event, err := NewLoanStatusEvent(LoanStatusChanged{
+		LoanID:          loanID,
+		BorrowerID:      borrowerID,
+		PrincipalAmount: NewMoney(1000000, "IDR"),
+		From:            LoanStatusApproved,
+		To:              LoanStatusInvested,
+		Reason:          "principal amount fully invested",
+	}, occurredAt)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, uuid.Nil, event.ID)
+	assert.Equal(t, "loan.invested", event.Type)
+	assert.Equal(t, LoanEventVersion, event.Version)
+	assert.Equal(t, loanID, event.AggregateID)
+	assert.Equal(t, occurredAt, event.OccurredAt)
+
+	var payload map[string]any
+	assert.Nil(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, map[string]any{
+		"loan_id":          loanID.String(),
+		"borrower_id":      borrowerID.String(),
+		"principal_amount": map[string]any{"amount": float64(1000000), "currency": "IDR"},
+		"previous_status":  "approved",
+		"status":           "invested",
+		"reason":           "principal amount fully invested",
+	}, payload)
+}
+
+func Test_WebhookSubscribes(t *testing.T) {
+	t.Parallel()
+
+	all := WebhookSubscription{EventTypes: []string{}}
+	assert.True(t, all.Subscribes("loan.approved"))
+	assert.True(t, all.Subscribes("loan.disbursed"))
+
+	some := WebhookSubscription{EventTypes: []string{"loan.invested", "loan.disbursed"}}
+	assert.True(t, some.Subscribes("loan.invested"))
+	assert.False(t, some.Subscribes("loan.approved"))
+}
@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActorTypeStaff    = "staff"
+	ActorTypeBorrower = "borrower"
+	ActorTypeInvestor = "investor"
+	ActorTypeSystem   = "system" //changes made by the service itself, e.g. expiry or a letter issued on full investment
+)
+
+// Actor is who a change of a loan is made by, the system has no ID
+type Actor struct {
+	Type string
+	ID   uuid.UUID
+}
+
+func StaffActor(id uuid.UUID) Actor    { return Actor{Type: ActorTypeStaff, ID: id} }
+func BorrowerActor(id uuid.UUID) Actor { return Actor{Type: ActorTypeBorrower, ID: id} }
+func InvestorActor(id uuid.UUID) Actor { return Actor{Type: ActorTypeInvestor, ID: id} }
+
+var SystemActor = Actor{Type: ActorTypeSystem}
+
+// LoanHistoryRecord is a loan_status_history row as stored: snapshots of the loan before and after a change
+type LoanHistoryRecord struct {
+	ID        int64
+	LoanID    uuid.UUID
+	Before    json.RawMessage //null for a loan's first record
+	After     json.RawMessage
+	UpdatedBy uuid.NullUUID
+	ActorType string //empty for records written before actors were recorded, unless they had an updated_by
+	RequestID string //empty for changes not made through the API
+	Reason    string
+	UpdatedAt time.Time
+}
+
+// LoanHistoryEntry is one change in the timeline of a loan
+type LoanHistoryEntry struct {
+	ID        int64         `json:"history_id"`
+	LoanID    uuid.UUID     `json:"loan_id"`
+	From      string        `json:"previous_status,omitempty"`
+	To        string        `json:"status"`
+	Actor     *HistoryActor `json:"actor,omitempty"` //none for old records whose actor is unknown
+	RequestID string        `json:"request_id,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+	ChangedAt time.Time     `json:"changed_at"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// HistoryActor is who made a change
+type HistoryActor struct {
+	Type string     `json:"type"`
+	ID   *uuid.UUID `json:"id,omitempty"` //none for the system
+}
+
+// FieldChange is a loan field whose value differs between the snapshots, nested fields are named with their path
+// e.g. approval.approved_by. Before or After is null when the field is missing from that snapshot.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
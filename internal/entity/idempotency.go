@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+const (
+	IdempotencyStatusInProgress = "in_progress" //the first request with the key is still being handled
+	IdempotencyStatusCompleted  = "completed"   //the response is stored and replayed to retries
+)
+
+// IdempotencyRecord remembers a mutating request sent with an Idempotency-Key header and the response it got,
+// keys are scoped to the caller that sent them
+type IdempotencyRecord struct {
+	Subject        string
+	Key            string
+	RequestHash    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	CreatedAt      time.Time
+}
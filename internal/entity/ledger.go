@@ -0,0 +1,210 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ledger account types. Each account is a type plus, for per-party accounts, the investor, loan or borrower it belongs to.
+const (
+	AccountInvestorWallet    = "investor_wallet"     //funds the platform holds for an investor
+	AccountLoanEscrow        = "loan_escrow"         //investors' stake in a loan, from pledge until repaid
+	AccountLoanReceivable    = "loan_receivable"     //principal the borrower still owes on a loan
+	AccountBorrower          = "borrower"            //money paid out to (credit) and received from (debit) a borrower
+	AccountPlatformFeeIncome = "platform_fee_income" //fees earned by the platform
+	AccountPlatformCash      = "platform_cash"       //money received into the platform's bank account
+)
+
+const (
+	SideDebit  = "debit"
+	SideCredit = "credit"
+)
+
+// Ledger transaction kinds
+const (
+	LedgerKindInvestmentPledged = "investment_pledged"
+	LedgerKindLoanDisbursed     = "loan_disbursed"
+	LedgerKindRepaymentReceived = "repayment_received"
+	LedgerKindWalletTopUp       = "wallet_top_up"
+	LedgerKindPledgeReleased    = "pledge_released"
+)
+
+// accountNormalSide is the side that increases an account, balances are reported on that side
+var accountNormalSide = map[string]string{
+	AccountInvestorWallet:    SideCredit,
+	AccountLoanEscrow:        SideCredit,
+	AccountLoanReceivable:    SideDebit,
+	AccountBorrower:          SideDebit,
+	AccountPlatformFeeIncome: SideCredit,
+	AccountPlatformCash:      SideDebit,
+}
+
+type LedgerAccount struct {
+	Type    string    `json:"account_type"`
+	OwnerID uuid.UUID `json:"owner_id"` //nil for platform accounts
+}
+
+// NormalSide returns debit or credit, empty for an unknown account type
+func (a LedgerAccount) NormalSide() string {
+	return accountNormalSide[a.Type]
+}
+
+type LedgerEntry struct {
+	Account LedgerAccount `json:"account"`
+	Side    string        `json:"side"`
+	Amount  Money         `json:"amount"`
+}
+
+// LedgerTransaction is a balanced group of entries, written at once and never changed afterwards
+type LedgerTransaction struct {
+	ID          uuid.UUID     `json:"transaction_id"`
+	Kind        string        `json:"kind"`
+	LoanID      uuid.UUID     `json:"loan_id"`
+	ReferenceID uuid.UUID     `json:"reference_id"` //investment, repayment... the transaction records
+	Entries     []LedgerEntry `json:"entries"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (t *LedgerTransaction) Debit(account LedgerAccount, amount Money) {
+	t.add(account, SideDebit, amount)
+}
+
+func (t *LedgerTransaction) Credit(account LedgerAccount, amount Money) {
+	t.add(account, SideCredit, amount)
+}
+
+// add skips zero amounts, entries always move money
+func (t *LedgerTransaction) add(account LedgerAccount, side string, amount Money) {
+	if amount.Amount == 0 {
+		return
+	}
+	t.Entries = append(t.Entries, LedgerEntry{Account: account, Side: side, Amount: amount})
+}
+
+// Balanced tells whether debits equal credits in every currency
+func (t LedgerTransaction) Balanced() bool {
+	net := map[string]int64{}
+	for _, e := range t.Entries {
+		if e.Side == SideDebit {
+			net[e.Amount.Currency] += e.Amount.Amount
+		} else {
+			net[e.Amount.Currency] -= e.Amount.Amount
+		}
+	}
+	for _, n := range net {
+		if n != 0 {
+			return false
+		}
+	}
+	return len(t.Entries) > 0
+}
+
+// PledgeTransaction moves an investment from the investor's wallet into the loan escrow
+func PledgeTransaction(investment LoanInvestment) LedgerTransaction {
+	txn := LedgerTransaction{
+		ID:          uuid.New(),
+		Kind:        LedgerKindInvestmentPledged,
+		LoanID:      investment.LoanID,
+		ReferenceID: investment.ID,
+	}
+	txn.Debit(LedgerAccount{Type: AccountInvestorWallet, OwnerID: investment.InvestorID}, investment.Amount)
+	txn.Credit(LedgerAccount{Type: AccountLoanEscrow, OwnerID: investment.LoanID}, investment.Amount)
+	return txn
+}
+
+// DisbursementTransaction books the principal as owed by the borrower and paid out to them
+func DisbursementTransaction(loanID, borrowerID uuid.UUID, principal Money) LedgerTransaction {
+	txn := LedgerTransaction{
+		ID:          uuid.New(),
+		Kind:        LedgerKindLoanDisbursed,
+		LoanID:      loanID,
+		ReferenceID: loanID,
+	}
+	txn.Debit(LedgerAccount{Type: AccountLoanReceivable, OwnerID: loanID}, principal)
+	txn.Credit(LedgerAccount{Type: AccountBorrower, OwnerID: borrowerID}, principal)
+	return txn
+}
+
+// RepaymentTransaction books money received from the borrower: the principal reduces the receivable and the
+// investors' escrow, principal and interest are credited to the investors' wallets and fees to the platform
+func RepaymentTransaction(repayment Repayment, borrowerID uuid.UUID) LedgerTransaction {
+	txn := LedgerTransaction{
+		ID:          uuid.New(),
+		Kind:        LedgerKindRepaymentReceived,
+		LoanID:      repayment.LoanID,
+		ReferenceID: repayment.ID,
+	}
+
+	principal := NewMoney(0, repayment.Amount.Currency)
+	fee := NewMoney(0, repayment.Amount.Currency)
+	for _, alloc := range repayment.Allocations {
+		principal.Amount += alloc.Principal.Amount
+		fee.Amount += alloc.Fee.Amount
+	}
+
+	txn.Debit(LedgerAccount{Type: AccountBorrower, OwnerID: borrowerID}, repayment.Amount)
+	txn.Debit(LedgerAccount{Type: AccountLoanEscrow, OwnerID: repayment.LoanID}, principal)
+	txn.Credit(LedgerAccount{Type: AccountLoanReceivable, OwnerID: repayment.LoanID}, principal)
+	for _, payout := range repayment.Payouts {
+		txn.Credit(LedgerAccount{Type: AccountInvestorWallet, OwnerID: payout.InvestorID}, payout.Amount)
+	}
+	txn.Credit(LedgerAccount{Type: AccountPlatformFeeIncome}, fee)
+	return txn
+}
+
+// TopUpTransaction books money received for an investor's wallet
+func TopUpTransaction(topUp WalletTopUp) LedgerTransaction {
+	txn := LedgerTransaction{
+		ID:          uuid.New(),
+		Kind:        LedgerKindWalletTopUp,
+		ReferenceID: topUp.ID,
+	}
+	txn.Debit(LedgerAccount{Type: AccountPlatformCash}, topUp.Amount)
+	txn.Credit(LedgerAccount{Type: AccountInvestorWallet, OwnerID: topUp.InvestorID}, topUp.Amount)
+	return txn
+}
+
+// ReleaseTransaction gives pledges that did not go through back to the investors' wallets
+func ReleaseTransaction(loanID uuid.UUID, holds []WalletHold) LedgerTransaction {
+	txn := LedgerTransaction{
+		ID:          uuid.New(),
+		Kind:        LedgerKindPledgeReleased,
+		LoanID:      loanID,
+		ReferenceID: loanID,
+	}
+	for _, hold := range holds {
+		txn.Debit(LedgerAccount{Type: AccountLoanEscrow, OwnerID: loanID}, hold.Amount)
+		txn.Credit(LedgerAccount{Type: AccountInvestorWallet, OwnerID: hold.InvestorID}, hold.Amount)
+	}
+	return txn
+}
+
+// AccountBalance is the sum of an account's entries in one currency, Balance being on the account's normal side
+type AccountBalance struct {
+	LedgerAccount
+	Debits  Money `json:"debits"`
+	Credits Money `json:"credits"`
+	Balance Money `json:"balance"`
+}
+
+// AccountFilter selects accounts by type and / or owner
+type AccountFilter struct {
+	Type     string
+	OwnerID  uuid.UUID
+	Currency string
+}
+
+// LedgerTotals are the debits and credits of the whole ledger in one currency
+type LedgerTotals struct {
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+}
+
+// LedgerIntegrity is the result of checking that debits equal credits, overall and per transaction
+type LedgerIntegrity struct {
+	Balanced               bool           `json:"balanced"`
+	Totals                 []LedgerTotals `json:"totals"`
+	UnbalancedTransactions []uuid.UUID    `json:"unbalanced_transactions"`
+}
@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LedgerTransactionBalanced(t *testing.T) {
+	t.Parallel()
+
+	wallet := LedgerAccount{Type: AccountInvestorWallet, OwnerID: uuid.New()}
+	escrow := LedgerAccount{Type: AccountLoanEscrow, OwnerID: uuid.New()}
+
+	var txn LedgerTransaction
+	assert.False(t, txn.Balanced())
+
+	txn.Debit(wallet, NewMoney(100, "IDR"))
+	assert.False(t, txn.Balanced())
+
+	txn.Credit(escrow, NewMoney(100, "USD"))
+	assert.False(t, txn.Balanced())
+
+	txn.Credit(escrow, NewMoney(100, "IDR"))
+	txn.Debit(wallet, NewMoney(100, "USD"))
+	txn.Debit(wallet, NewMoney(0, "IDR"))
+	assert.True(t, txn.Balanced())
+	assert.Len(t, txn.Entries, 4)
+}
+
+func Test_LoanLifecycleTransactions(t *testing.T) {
+	t.Parallel()
+
+	loanID, borrowerID := uuid.New(), uuid.New()
+	first := LoanInvestment{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: NewMoney(600, "IDR")}
+	second := LoanInvestment{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: NewMoney(400, "IDR")}
+
+	repayment := Repayment{
+		ID:     uuid.New(),
+		LoanID: loanID,
+		Amount: NewMoney(1110, "IDR"),
+		Allocations: []RepaymentAllocation{
+			{Fee: NewMoney(10, "IDR"), Interest: NewMoney(100, "IDR"), Principal: NewMoney(1000, "IDR")},
+		},
+		Payouts: []InvestorPayout{
+			{InvestorID: first.InvestorID, Amount: NewMoney(660, "IDR")},
+			{InvestorID: second.InvestorID, Amount: NewMoney(440, "IDR")},
+		},
+	}
+
+	txns := []LedgerTransaction{
+		PledgeTransaction(first),
+		PledgeTransaction(second),
+		DisbursementTransaction(loanID, borrowerID, NewMoney(1000, "IDR")),
+		RepaymentTransaction(repayment, borrowerID),
+	}
+
+	// signed debits minus credits per account
+	net := map[LedgerAccount]int64{}
+	for _, txn := range txns {
+		assert.True(t, txn.Balanced(), txn.Kind)
+		for _, e := range txn.Entries {
+			if e.Side == SideDebit {
+				net[e.Account] += e.Amount.Amount
+			} else {
+				net[e.Account] -= e.Amount.Amount
+			}
+		}
+	}
+
+	// once repaid, nothing is left in escrow or receivable; investors got their interest and the platform its fee
+	assert.Equal(t, int64(0), net[LedgerAccount{Type: AccountLoanEscrow, OwnerID: loanID}])
+	assert.Equal(t, int64(0), net[LedgerAccount{Type: AccountLoanReceivable, OwnerID: loanID}])
+	assert.Equal(t, int64(-60), net[LedgerAccount{Type: AccountInvestorWallet, OwnerID: first.InvestorID}])
+	assert.Equal(t, int64(-40), net[LedgerAccount{Type: AccountInvestorWallet, OwnerID: second.InvestorID}])
+	assert.Equal(t, int64(-10), net[LedgerAccount{Type: AccountPlatformFeeIncome}])
+	assert.Equal(t, int64(110), net[LedgerAccount{Type: AccountBorrower, OwnerID: borrowerID}])
+}
+
+func Test_WalletTransactions(t *testing.T) {
+	t.Parallel()
+
+	investorID, loanID := uuid.New(), uuid.New()
+
+	topUp := TopUpTransaction(WalletTopUp{ID: uuid.New(), InvestorID: investorID, Amount: NewMoney(1000, "IDR")})
+	assert.True(t, topUp.Balanced())
+	assert.Equal(t, LedgerKindWalletTopUp, topUp.Kind)
+	assert.Equal(t, []LedgerEntry{
+		{Account: LedgerAccount{Type: AccountPlatformCash}, Side: SideDebit, Amount: NewMoney(1000, "IDR")},
+		{Account: LedgerAccount{Type: AccountInvestorWallet, OwnerID: investorID}, Side: SideCredit, Amount: NewMoney(1000, "IDR")},
+	}, topUp.Entries)
+
+	holds := []WalletHold{
+		{InvestorID: investorID, LoanID: loanID, Amount: NewMoney(600, "IDR")},
+		{InvestorID: uuid.New(), LoanID: loanID, Amount: NewMoney(400, "IDR")},
+	}
+	release := ReleaseTransaction(loanID, holds)
+	assert.True(t, release.Balanced())
+	assert.Equal(t, loanID, release.LoanID)
+	assert.Len(t, release.Entries, 4)
+	assert.Equal(t, LedgerEntry{Account: LedgerAccount{Type: AccountLoanEscrow, OwnerID: loanID}, Side: SideDebit, Amount: NewMoney(600, "IDR")},
+		release.Entries[0])
+	assert.Equal(t, LedgerEntry{Account: LedgerAccount{Type: AccountInvestorWallet, OwnerID: investorID}, Side: SideCredit, Amount: NewMoney(600, "IDR")},
+		release.Entries[1])
+}
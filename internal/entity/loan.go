@@ -10,17 +10,41 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	LoanStatusProposed  = "proposed"
+	LoanStatusApproved  = "approved"
+	LoanStatusInvested  = "invested"
+	LoanStatusDisbursed = "disbursed"
+	LoanStatusRejected  = "rejected"
+	LoanStatusCancelled = "cancelled"
+	LoanStatusExpired   = "expired"
+	LoanStatusRepaying  = "repaying"
+	LoanStatusClosed    = "closed"
+)
+
+const (
+	RepaymentMethodFlat    = "flat"    //same principal and interest every month, interest on the original principal
+	RepaymentMethodAnnuity = "annuity" //same instalment every month, interest on the outstanding principal
+)
+
 type Loan struct {
 	ID              uuid.UUID `json:"loan_id"`
 	BorrowerID      uuid.UUID `json:"borrower_id"`
-	PrincipalAmount int64     `json:"principal_amount"`
-	InterestRate    float32   `json:"interest_rate"`
+	PrincipalAmount Money     `json:"principal_amount"`
+	InterestRate    Rate      `json:"interest_rate"` //per annum
+	TenorMonths     int       `json:"tenor_months"`
+	RepaymentMethod string    `json:"repayment_method"`
 	AgreementLetter string    `json:"agreement_letter"`
 	Status          string    `json:"loan_status"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	DisburseAt      time.Time `json:"disburse_at"`
-	Returns         float64   `json:"returns"`
+	FundingDeadline time.Time `json:"funding_deadline"` //an approved loan not fully invested by then expires
+	Returns         Money     `json:"returns"`          //total interest earned by investors
+	RemainingAmount Money     `json:"remaining_amount"` //principal not yet covered by investments
+
+	Approval        *LoanApproval    `json:"approval,omitempty"`
+	InvestorReturns []InvestorReturn `json:"investor_returns,omitempty"`
 }
 
 func (l Loan) Value() (driver.Value, error) {
@@ -36,39 +60,181 @@ func (l *Loan) Scan(value interface{}) error {
 	return json.Unmarshal(b, &l)
 }
 
+// LoanStatusChange describes a single status transition to be persisted together with its history record
+type LoanStatusChange struct {
+	LoanID    uuid.UUID
+	From      string
+	To        string
+	UpdatedBy uuid.UUID
+	Reason    string
+
+	Approval        *LoanApproval //approval evidence, only set when moving to approved
+	FundingDeadline time.Time     //end of the funding window, only set when moving to approved
+}
+
+// Actor is the staff behind the change, or the system when there is none
+func (c LoanStatusChange) Actor() Actor {
+	if c.UpdatedBy == uuid.Nil {
+		return SystemActor
+	}
+	return StaffActor(c.UpdatedBy)
+}
+
+// LoanApproval is the evidence captured by staff when approving a loan
+type LoanApproval struct {
+	LoanID           uuid.UUID `json:"-"`
+	PictureProof     string    `json:"picture_proof"`
+	FieldValidatorID uuid.UUID `json:"field_validator_id"`
+	ApprovedBy       uuid.UUID `json:"approved_by"`
+	ApprovalDate     time.Time `json:"approval_date"`
+}
+
+const (
+	InvestmentStatusActive    = "active"
+	InvestmentStatusWithdrawn = "withdrawn" //taken back by the investor while the loan was still open for investment
+)
+
 type LoanInvestment struct {
 	ID         uuid.UUID `json:"loan_investment_id"`
 	LoanID     uuid.UUID `json:"loan_id"`
 	InvestorID uuid.UUID `json:"investor_id"`
-	Amount     int64     `json:"amount"`
+	Amount     Money     `json:"amount"`
 	InvestedAt time.Time `json:"invested_at"`
 }
 
+// InvestorReturn is what a single investment gets back from its loan: its pro-rata share of the
+// principal and of the total interest
+type InvestorReturn struct {
+	InvestmentID   uuid.UUID `json:"loan_investment_id"`
+	InvestorID     uuid.UUID `json:"investor_id"`
+	Amount         Money     `json:"amount"`
+	PrincipalShare Money     `json:"principal_share"`
+	InterestShare  Money     `json:"interest_share"`
+	TotalReturn    Money     `json:"total_return"`
+}
+
+// InvestmentDetail is an investment together with the state of its loan and what it is expected to earn
+type InvestmentDetail struct {
+	LoanInvestment
+	LoanStatus       string `json:"loan_status"`
+	PrincipalAmount  Money  `json:"principal_amount"`
+	InterestRate     Rate   `json:"interest_rate"`
+	TenorMonths      int    `json:"tenor_months"`
+	RepaymentMethod  string `json:"repayment_method"`
+	ShareOfPrincipal Rate   `json:"share_of_principal"` //percentage of the loan principal funded by this investment
+	PrincipalShare   Money  `json:"principal_share"`
+	InterestShare    Money  `json:"interest_share"`
+	ProjectedReturn  Money  `json:"projected_return"` //principal share + interest share
+	AgreementLetter  string `json:"agreement_letter,omitempty"`
+}
+
+// ExposureStatuses are the statuses of loans whose pledges count towards an investor's exposure
+var ExposureStatuses = []string{LoanStatusApproved, LoanStatusInvested, LoanStatusDisbursed, LoanStatusRepaying}
+
+// InvestorTotals is what an investor has pledged, in the currency of a loan, on that loan and on all active loans
+type InvestorTotals struct {
+	OnLoan   int64
+	Exposure int64
+}
+
+// InvestmentFilter selects investments of a loan, of an investor, or both
+type InvestmentFilter struct {
+	LoanID     uuid.UUID
+	InvestorID uuid.UUID
+
+	//CoInvestors, together with InvestorID, selects every investment on the loans the investor invested in
+	CoInvestors bool
+}
+
 type LoanSubmitRequest struct {
-	BorrowerID      string  `json:"-"`
-	PrincipalAmount int64   `json:"principal_amount"`
-	InterestRate    float32 `json:"interest_rate"`
-	Reason          string  `json:"reason"`
+	BorrowerID      string `json:"-"`
+	PrincipalAmount Money  `json:"principal_amount"`
+	InterestRate    Rate   `json:"interest_rate"`
+	TenorMonths     int    `json:"tenor_months"`
+	RepaymentMethod string `json:"repayment_method"` //flat when empty
+	Reason          string `json:"reason"`
 }
 
 type LoanUpdateRequest struct {
-	LoanID  string `json:"-"`
-	Status  string `json:"status"`
-	StaffID string `json:"-"`
+	LoanID           string                `json:"-" form:"-"`
+	Status           string                `json:"status" form:"status"`
+	Reason           string                `json:"reason" form:"reason"`
+	FieldValidatorID string                `json:"field_validator_id" form:"field_validator_id"`
+	ApprovalDate     string                `json:"approval_date" form:"approval_date"`
+	PictureProof     *multipart.FileHeader `json:"-" form:"picture_proof"`
+	PictureProofLink string                `json:"-" form:"-"`
+	ApprovedAt       time.Time             `json:"-" form:"-"`
+	StaffID          string                `json:"-" form:"-"`
 }
 
 type LoanInvestRequest struct {
 	LoanID     string    `json:"-"`
-	Amount     int64     `json:"amount"`
+	Amount     Money     `json:"amount"`
 	InvestorID uuid.UUID `json:"-"`
 }
 
+type LoanWithdrawRequest struct {
+	LoanID       string
+	InvestmentID string
+	InvestorID   uuid.UUID
+}
+
+// InvestmentWithdrawal is a pledge taken back by its investor, RemainingAmount is what the loan still needs afterwards
+type InvestmentWithdrawal struct {
+	InvestmentID    uuid.UUID `json:"loan_investment_id"`
+	LoanID          uuid.UUID `json:"loan_id"`
+	InvestorID      uuid.UUID `json:"investor_id"`
+	Amount          Money     `json:"amount"`
+	WithdrawnAt     time.Time `json:"withdrawn_at"`
+	RemainingAmount Money     `json:"remaining_amount"`
+}
+
 type LoanDisburseRequest struct {
 	LoanID              string
 	BorrowerID          int64
 	StaffID             string
-	LoanAgreementDocs   *multipart.FileHeader `form:"agreement_file" binding:"required"`
+	LoanAgreementDocs   *multipart.FileHeader `form:"agreement_file"` //signed copy, the generated letter is used when missing
 	DisbursementDate    string                `form:"disbursement_date" binding:"required"`
 	AgreementLetterLink string
 	DisburseAt          time.Time
 }
+
+type LoanListRequest struct {
+	Statuses        []string  `form:"status"`
+	BorrowerID      string    `form:"borrower_id"`
+	Currency        string    `form:"currency"`
+	MinPrincipal    *int64    `form:"min_principal"` //minor units
+	MaxPrincipal    *int64    `form:"max_principal"` //minor units
+	MinInterestRate string    `form:"min_interest_rate"`
+	MaxInterestRate string    `form:"max_interest_rate"`
+	CreatedFrom     string    `form:"created_from"`
+	CreatedTo       string    `form:"created_to"`
+	Sort            string    `form:"sort"`
+	Cursor          string    `form:"cursor"`
+	Limit           int       `form:"limit"`
+	CreatedAfter    time.Time `form:"-"`
+	CreatedBefore   time.Time `form:"-"`
+}
+
+// LoanFilter is the repository query behind a loan listing, the cursor fields hold the last row of the previous page
+type LoanFilter struct {
+	Statuses        []string
+	BorrowerID      uuid.UUID
+	Currency        string
+	MinPrincipal    *int64
+	MaxPrincipal    *int64
+	MinInterestRate *Rate
+	MaxInterestRate *Rate
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	DeadlineBefore  time.Time //funding deadline strictly before, loans without a deadline never match
+	Ascending       bool
+	AfterCreatedAt  time.Time
+	AfterID         uuid.UUID
+	Limit           int
+}
+
+type LoanPage struct {
+	Loans      []Loan `json:"loans"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
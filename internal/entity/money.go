@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "IDR"
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("currency must be an ISO 4217 code")
+	ErrInvalidRate      = errors.New("rate must be a non-negative percentage with at most 2 decimals")
+)
+
+// Money is an amount in the currency's minor units (e.g. cents), it never goes through floating point
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Validate checks the currency is a 3 letter upper case ISO code
+func (m Money) Validate() error {
+	if len(m.Currency) != 3 || strings.ToUpper(m.Currency) != m.Currency {
+		return ErrInvalidCurrency
+	}
+	for _, r := range m.Currency {
+		if r < 'A' || r > 'Z' {
+			return ErrInvalidCurrency
+		}
+	}
+	return nil
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+}
+
+// Rate is a percentage in basis points: 1050 is 10.50%. In JSON it is written as the percentage (10.50)
+// and read from a number or a string, parsed as decimal text rather than as a float.
+type Rate int64
+
+const bpsPerPercent = 100
+
+// ParseRate parses a percentage such as "10", "10.5" or "10.50"
+func ParseRate(s string) (Rate, error) {
+	whole, frac, hasFrac := strings.Cut(strings.TrimSpace(s), ".")
+	if whole == "" || (hasFrac && (frac == "" || len(frac) > 2)) {
+		return 0, ErrInvalidRate
+	}
+
+	w, err := strconv.ParseUint(whole, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidRate
+	}
+
+	var f uint64
+	if hasFrac {
+		if len(frac) == 1 {
+			frac += "0"
+		}
+		f, err = strconv.ParseUint(frac, 10, 8)
+		if err != nil {
+			return 0, ErrInvalidRate
+		}
+	}
+
+	return Rate(int64(w)*bpsPerPercent + int64(f)), nil
+}
+
+// Bps returns the rate in basis points
+func (r Rate) Bps() int64 {
+	return int64(r)
+}
+
+func (r Rate) String() string {
+	sign := ""
+	v := int64(r)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/bpsPerPercent, v%bpsPerPercent)
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(b []byte) error {
+	rate, err := ParseRate(string(bytes.Trim(b, `"`)))
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
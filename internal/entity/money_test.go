@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want Rate
+		ok   bool
+	}{
+		{"10", 1000, true},
+		{"10.5", 1050, true},
+		{"10.50", 1050, true},
+		{"0.01", 1, true},
+		{"12.3", 1230, true},
+		{"10.505", 0, false},
+		{"-1", 0, false},
+		{"10.", 0, false},
+		{".5", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		assert.Equal(t, tt.ok, err == nil, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func Test_RateJSON(t *testing.T) {
+	t.Parallel()
+
+	var payload struct {
+		Rate Rate `json:"rate"`
+	}
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"rate": 10.5}`), &payload))
+	assert.Equal(t, Rate(1050), payload.Rate)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"rate": "12.25"}`), &payload))
+	assert.Equal(t, Rate(1225), payload.Rate)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"rate": 1e1}`), &payload))
+
+	out, err := json.Marshal(payload)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"rate":12.25}`, string(out))
+}
+
+func Test_Money(t *testing.T) {
+	t.Parallel()
+
+	a := NewMoney(1000, "IDR")
+	b := NewMoney(250, "IDR")
+
+	sum, err := a.Add(b)
+	assert.Nil(t, err)
+	assert.Equal(t, NewMoney(1250, "IDR"), sum)
+
+	diff, err := a.Sub(b)
+	assert.Nil(t, err)
+	assert.Equal(t, NewMoney(750, "IDR"), diff)
+
+	_, err = a.Add(NewMoney(1, "USD"))
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	assert.Nil(t, a.Validate())
+	assert.NotNil(t, NewMoney(1, "idr").Validate())
+	assert.NotNil(t, NewMoney(1, "").Validate())
+}
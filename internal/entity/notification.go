@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationKindLoanInvested = "loan_invested" //the loan is fully funded, the investor gets the agreement letter link
+)
+
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed" //given up after the last attempt
+)
+
+// Notification is a message owed to one investor about one loan, with its delivery state
+type Notification struct {
+	ID            uuid.UUID  `json:"notification_id"`
+	Kind          string     `json:"kind"`
+	LoanID        uuid.UUID  `json:"loan_id"`
+	InvestorID    uuid.UUID  `json:"investor_id"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	//filled in when the notification is claimed for sending
+	Recipient string `json:"-"` //the investor's e-mail, empty when none is on file
+	Loan      Loan   `json:"-"`
+	Amount    Money  `json:"-"` //what the investor pledged on the loan
+
+	AgreementKey string `json:"-"` //document store key of the loan's latest generated agreement letter, empty when it has none
+}
+
+// InvestorContact is where notifications to an investor are sent
+type InvestorContact struct {
+	InvestorID uuid.UUID `json:"investor_id"`
+	Email      string    `json:"email"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ContactUpdateRequest struct {
+	InvestorID uuid.UUID `json:"-"`
+	Email      string    `json:"email"`
+}
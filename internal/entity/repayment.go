@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repayment is money received from the borrower and how it was allocated to the instalments
+type Repayment struct {
+	ID          uuid.UUID             `json:"repayment_id"`
+	LoanID      uuid.UUID             `json:"loan_id"`
+	Amount      Money                 `json:"amount"`
+	PaidAt      time.Time             `json:"paid_at"`
+	RecordedBy  uuid.UUID             `json:"recorded_by"`
+	CreatedAt   time.Time             `json:"created_at"`
+	LoanStatus  string                `json:"loan_status"` //loan status once the repayment is applied
+	Allocations []RepaymentAllocation `json:"allocations"`
+	Payouts     []InvestorPayout      `json:"payouts"`
+}
+
+// Summary describes the repayment and how it was allocated to the instalments, as recorded in the loan history
+func (r Repayment) Summary() string {
+	allocations := make([]string, 0, len(r.Allocations))
+	for _, alloc := range r.Allocations {
+		allocations = append(allocations, fmt.Sprintf("instalment %d (fee %d, interest %d, principal %d)",
+			alloc.InstalmentNumber, alloc.Fee.Amount, alloc.Interest.Amount, alloc.Principal.Amount))
+	}
+	return fmt.Sprintf("repayment %s of %s recorded, allocated to %s", r.ID, r.Amount.String(), strings.Join(allocations, ", "))
+}
+
+// RepaymentAllocation is the part of a repayment that went to one instalment
+type RepaymentAllocation struct {
+	InstalmentNumber int   `json:"instalment_number"`
+	Fee              Money `json:"fee"`
+	Interest         Money `json:"interest"`
+	Principal        Money `json:"principal"`
+}
+
+// InvestorPayout is the part of a repayment passed on to one investment, pro rata to its amount.
+// Fees are kept by the platform, only principal and interest are paid out.
+type InvestorPayout struct {
+	ID           uuid.UUID `json:"payout_id"`
+	RepaymentID  uuid.UUID `json:"repayment_id"`
+	LoanID       uuid.UUID `json:"loan_id"`
+	InvestmentID uuid.UUID `json:"loan_investment_id"`
+	InvestorID   uuid.UUID `json:"investor_id"`
+	Principal    Money     `json:"principal"`
+	Interest     Money     `json:"interest"`
+	Amount       Money     `json:"amount"` //principal + interest
+	PaidAt       time.Time `json:"paid_at"`
+}
+
+// PayoutFilter selects payouts of an investor, optionally narrowed to one loan
+type PayoutFilter struct {
+	InvestorID uuid.UUID
+	LoanID     uuid.UUID
+}
+
+// RepaymentRecord is a repayment together with everything it changes, persisted in one transaction.
+// LoanUpdatedAt is the loan version the allocation was computed against.
+type RepaymentRecord struct {
+	Repayment     Repayment
+	Before        []LoanInstalment //instalments touched by the repayment, as they were
+	After         []LoanInstalment //the same instalments once the repayment is applied
+	LoanUpdatedAt time.Time
+	StatusChange  *LoanStatusChange //set when the repayment moves the loan to repaying or closed
+}
+
+type LoanRepaymentRequest struct {
+	LoanID  string    `json:"-"`
+	Amount  Money     `json:"amount"`
+	PaidOn  string    `json:"paid_at"` //YYYY-MM-DD, today when empty
+	PaidAt  time.Time `json:"-"`
+	StaffID string    `json:"-"`
+}
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	InstalmentStatusDue           = "due"
+	InstalmentStatusPartiallyPaid = "partially_paid"
+	InstalmentStatusPaid          = "paid"
+)
+
+// LoanInstalment is a single monthly repayment due by the borrower
+type LoanInstalment struct {
+	LoanID               uuid.UUID `json:"-"`
+	Number               int       `json:"number"`
+	DueDate              time.Time `json:"due_date"`
+	Principal            Money     `json:"principal"`
+	Interest             Money     `json:"interest"`
+	Fee                  Money     `json:"fee"`
+	Amount               Money     `json:"amount"`                //principal + interest + fee
+	OutstandingPrincipal Money     `json:"outstanding_principal"` //principal left after this instalment is paid
+	PrincipalPaid        Money     `json:"principal_paid"`
+	InterestPaid         Money     `json:"interest_paid"`
+	FeePaid              Money     `json:"fee_paid"`
+	Status               string    `json:"status"`
+}
+
+// Outstanding is what is still to be paid on the instalment
+func (i LoanInstalment) Outstanding() int64 {
+	return i.Amount.Amount - i.PrincipalPaid.Amount - i.InterestPaid.Amount - i.FeePaid.Amount
+}
+
+// LoanSchedule is the repayment plan generated when a loan is disbursed
+type LoanSchedule struct {
+	LoanID          uuid.UUID        `json:"loan_id"`
+	BorrowerID      uuid.UUID        `json:"borrower_id"`
+	LoanStatus      string           `json:"loan_status"`
+	RepaymentMethod string           `json:"repayment_method"`
+	TenorMonths     int              `json:"tenor_months"`
+	TotalPrincipal  Money            `json:"total_principal"`
+	TotalInterest   Money            `json:"total_interest"`
+	TotalAmount     Money            `json:"total_amount"`
+	TotalPaid       Money            `json:"total_paid"`
+	Instalments     []LoanInstalment `json:"instalments"`
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HoldStatusHeld     = "held"     //reserved for a pledge, the loan is not disbursed yet
+	HoldStatusCaptured = "captured" //the loan was disbursed with the pledged funds
+	HoldStatusReleased = "released" //the pledge did not go through, the funds are available again
+)
+
+// Wallet is an investor's funds in one currency. Available can be pledged, Held is reserved for pledges
+// on loans that are not disbursed yet.
+type Wallet struct {
+	InvestorID uuid.UUID    `json:"investor_id"`
+	Available  Money        `json:"available"`
+	Held       Money        `json:"held"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Holds      []WalletHold `json:"holds"`
+}
+
+// WalletHold is the reservation made on a wallet by a single pledge
+type WalletHold struct {
+	ID           uuid.UUID `json:"hold_id"`
+	InvestorID   uuid.UUID `json:"investor_id"`
+	LoanID       uuid.UUID `json:"loan_id"`
+	InvestmentID uuid.UUID `json:"loan_investment_id"`
+	Amount       Money     `json:"amount"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WalletTopUpRequest struct {
+	InvestorID uuid.UUID `json:"-"`
+	Amount     Money     `json:"amount"`
+	StaffID    string    `json:"-"`
+}
+
+// WalletTopUp is money received into an investor's wallet
+type WalletTopUp struct {
+	ID         uuid.UUID `json:"top_up_id"`
+	InvestorID uuid.UUID `json:"investor_id"`
+	Amount     Money     `json:"amount"`
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead" //given up after the last attempt, staff may send it again
+)
+
+// WebhookSubscription is a partner endpoint pushed the loan events it subscribed to
+type WebhookSubscription struct {
+	ID         uuid.UUID `json:"webhook_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"` //signs the deliveries, only returned when the webhook is created
+	EventTypes []string  `json:"event_types"`      //every loan event when empty
+	Active     bool      `json:"active"`
+	CreatedBy  uuid.UUID `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Subscribes tells whether events of eventType are delivered to the webhook
+func (w WebhookSubscription) Subscribes(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event owed to one webhook, with its delivery state
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"delivery_id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	LoanID         uuid.UUID       `json:"loan_id"`
+	Payload        json.RawMessage `json:"payload"` //the event envelope, sent as the request body
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	//filled in when the delivery is claimed for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookCreateRequest struct {
+	URL        string    `json:"url" binding:"required"`
+	Secret     string    `json:"secret"` //generated when empty
+	EventTypes []string  `json:"event_types"`
+	StaffID    uuid.UUID `json:"-"`
+}
+
+// WebhookUpdateRequest changes the fields that are set
+type WebhookUpdateRequest struct {
+	ID         uuid.UUID `json:"-"`
+	URL        *string   `json:"url"`
+	EventTypes *[]string `json:"event_types"`
+	Active     *bool     `json:"active"`
+}
+
+type WebhookDeliveryFilter struct {
+	WebhookID uuid.UUID `form:"-"`
+	Status    string    `form:"status"`
+	Limit     int       `form:"limit"`
+}
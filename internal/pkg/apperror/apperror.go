@@ -0,0 +1,120 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies a domain error so transport layers can map it without knowing the error itself
+type Kind int
+
+const (
+	KindNotFound Kind = iota + 1
+	KindConflict
+	KindValidation
+	KindInvalidState
+	KindForbidden
+)
+
+// Error is a domain error carrying its kind and a stable, machine-readable code clients can switch on
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error //underlying cause, optional
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches domain errors by kind and code, so a wrapped or re-created error still equals its sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
+
+// Wrap returns a copy of e with err attached as the underlying cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// Withf returns a copy of e with a more specific message
+func (e *Error) Withf(format string, args ...any) *Error {
+	wrapped := *e
+	wrapped.Message = fmt.Sprintf(format, args...)
+	return &wrapped
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func InvalidState(code, message string) *Error {
+	return &Error{Kind: KindInvalidState, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// As returns the domain error inside err, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+var (
+	ErrLoanNotFound                = NotFound("loan_not_found", "loan not found")
+	ErrInvestmentNotFound          = NotFound("investment_not_found", "investment not found")
+	ErrAgreementNotFound           = NotFound("agreement_not_found", "agreement letter not found")
+	ErrDocumentNotFound            = NotFound("document_not_found", "document not found")
+	ErrContactNotFound             = NotFound("contact_not_found", "no contact details on file")
+	ErrWebhookNotFound             = NotFound("webhook_not_found", "webhook not found")
+	ErrDeliveryNotFound            = NotFound("delivery_not_found", "webhook delivery not found")
+	ErrInvalidLoanID               = Validation("invalid_loan_id", "loan ID must be a UUID")
+	ErrLoanConcurrentUpdate        = Conflict("loan_concurrent_update", "loan has been updated by another staff")
+	ErrInvalidTransition           = InvalidState("invalid_transition", "loan status transition is not allowed")
+	ErrMissingApprovalEvidence     = Validation("missing_approval_evidence", "approval requires picture proof, field validator ID and approval date")
+	ErrInvestmentExceedsRemaining  = Validation("investment_exceeds_remaining", "pledged fund exceeds the remaining loan value")
+	ErrForbidden                   = Forbidden("forbidden", "caller is not allowed to perform this action")
+	ErrInvalidAmount               = Validation("invalid_amount", "amount must be positive and have an ISO 4217 currency")
+	ErrInvalidRate                 = Validation("invalid_rate", "rate must be a positive percentage with at most 2 decimals")
+	ErrCurrencyMismatch            = Validation("currency_mismatch", "amount currency differs from the loan currency")
+	ErrInvalidTenor                = Validation("invalid_tenor", "tenor must be between 1 and 360 months")
+	ErrInvalidRepaymentMethod      = Validation("invalid_repayment_method", "repayment method must be flat or annuity")
+	ErrScheduleNotAvailable        = InvalidState("schedule_not_available", "repayment schedule is generated once the loan is disbursed")
+	ErrRepaymentExceedsOutstanding = Validation("repayment_exceeds_outstanding", "repayment is larger than what is left to repay")
+	ErrInvalidEmail                = Validation("invalid_email", "email must be a single valid address")
+	ErrInvalidWebhook              = Validation("invalid_webhook", "webhook needs an absolute http(s) URL and known event types")
+	ErrDeliveryNotDead             = InvalidState("delivery_not_dead", "only dead deliveries can be sent again")
+	ErrInsufficientBalance         = Validation("insufficient_balance", "pledge is larger than the available wallet balance")
+	ErrBelowMinTicket              = Validation("investment_below_min_ticket", "pledge is smaller than the minimum ticket")
+	ErrNotInStep                   = Validation("investment_not_in_step", "pledge is not a multiple of the investment step")
+	ErrAboveMaxLoanShare           = Validation("investment_above_max_loan_share", "pledge takes the investor above the maximum share of one loan")
+	ErrAboveMaxExposure            = Validation("investment_above_max_exposure", "pledge takes the investor above the maximum total exposure")
+	ErrAgreementNotAvailable       = InvalidState("agreement_not_available", "agreement letter is issued once the loan is fully invested")
+	ErrFundingDeadlinePassed       = InvalidState("funding_deadline_passed", "loan funding deadline has passed")
+	ErrIdempotencyKeyReused        = Validation("idempotency_key_reused", "idempotency key was already used with a different request")
+	ErrIdempotencyInProgress       = Conflict("idempotency_request_in_progress", "a request with this idempotency key is still being processed")
+)
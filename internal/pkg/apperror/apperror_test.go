@@ -0,0 +1,33 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Is(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("update loan: %w", ErrLoanNotFound.Wrap(errors.New("sql: no rows in result set")))
+	assert.True(t, errors.Is(wrapped, ErrLoanNotFound))
+	assert.False(t, errors.Is(wrapped, ErrLoanConcurrentUpdate))
+
+	custom := ErrInvalidTransition.Withf("loan cannot transition from %s to %s", "proposed", "disbursed")
+	assert.True(t, errors.Is(custom, ErrInvalidTransition))
+	assert.Equal(t, "loan cannot transition from proposed to disbursed", custom.Error())
+}
+
+func Test_As(t *testing.T) {
+	t.Parallel()
+
+	appErr, ok := As(fmt.Errorf("invest: %w", ErrInvestmentExceedsRemaining))
+	assert.True(t, ok)
+	assert.Equal(t, KindValidation, appErr.Kind)
+	assert.Equal(t, "investment_exceeds_remaining", appErr.Code)
+
+	_, ok = As(errors.New("db error"))
+	assert.False(t, ok)
+}
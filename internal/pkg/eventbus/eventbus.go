@@ -0,0 +1,76 @@
+// Package eventbus holds the publishers the outbox relay hands domain events to. A message is an opaque body,
+// the JSON event envelope, and a key: messages with the same key must be delivered in publication order.
+package eventbus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type Message struct {
+	Key  string
+	Body []byte
+}
+
+// Memory keeps published messages in memory, for tests and local runs
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (p *Memory) Publish(ctx context.Context, key string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, Message{Key: key, Body: append([]byte(nil), body...)})
+	return nil
+}
+
+// Messages returns what has been published so far, oldest first
+func (p *Memory) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Message(nil), p.messages...)
+}
+
+// File appends every published message as one line of a JSON lines file, which other processes can tail
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFile opens name for appending, creating it and its directory when missing
+func NewFile(name string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &File{file: f}, nil
+}
+
+// Publish writes the body followed by a newline and syncs it, so a message reported as published survives a crash
+func (p *File) Publish(ctx context.Context, key string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line := append(append([]byte(nil), body...), '\n')
+	if _, err := p.file.Write(line); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+func (p *File) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,49 @@
+package eventbus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Memory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := NewMemory()
+	body := []byte(`{"event_type":"loan.approved"}`)
+
+	assert.Nil(t, p.Publish(ctx, "loan-1", body))
+	assert.Nil(t, p.Publish(ctx, "loan-2", []byte(`{"event_type":"loan.invested"}`)))
+	body[0] = 'x' //the publisher keeps its own copy
+
+	assert.Equal(t, []Message{
+		{Key: "loan-1", Body: []byte(`{"event_type":"loan.approved"}`)},
+		{Key: "loan-2", Body: []byte(`{"event_type":"loan.invested"}`)},
+	}, p.Messages())
+}
+
+func Test_File(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	name := filepath.Join(t.TempDir(), "events", "loan-events.jsonl")
+
+	p, err := NewFile(name)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Publish(ctx, "loan-1", []byte(`{"event_type":"loan.approved"}`)))
+	assert.Nil(t, p.Close())
+
+	//reopening appends to what is already there
+	p, err = NewFile(name)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Publish(ctx, "loan-1", []byte(`{"event_type":"loan.invested"}`)))
+	assert.Nil(t, p.Close())
+
+	content, err := os.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"event_type\":\"loan.approved\"}\n{\"event_type\":\"loan.invested\"}\n", string(content))
+}
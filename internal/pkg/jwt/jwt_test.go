@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validClaims() Claims {
+	return Claims{
+		Subject:   "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
+		Role:      "staff",
+		Issuer:    "loan-service-test",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func Test_VerifyHS256(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("local-test-secret")
+	v, err := NewVerifier(WithHMACSecret(secret), WithIssuer("loan-service-test"))
+	assert.Nil(t, err)
+
+	t.Run("valid token", func(t *testing.T) {
+		token, err := SignHS256(validClaims(), secret)
+		assert.Nil(t, err)
+
+		claims, err := v.Verify(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "staff", claims.Role)
+		assert.Equal(t, "1e938a3c-3752-49a6-a2a6-43be38c6aa82", claims.Subject)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, _ := SignHS256(validClaims(), []byte("another-secret"))
+		_, err := v.Verify(token)
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		token, _ := SignHS256(validClaims(), secret)
+		other := validClaims()
+		other.Role = "investor"
+		forged, _ := SignHS256(other, []byte("x"))
+
+		parts := strings.Split(token, ".")
+		forgedParts := strings.Split(forged, ".")
+		_, err := v.Verify(parts[0] + "." + forgedParts[1] + "." + parts[2])
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		claims := validClaims()
+		claims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+		token, _ := SignHS256(claims, secret)
+		_, err := v.Verify(token)
+		assert.Equal(t, ErrExpiredToken, err)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := validClaims()
+		claims.Issuer = "someone-else"
+		token, _ := SignHS256(claims, secret)
+		_, err := v.Verify(token)
+		assert.Equal(t, ErrInvalidIssuer, err)
+	})
+
+	t.Run("alg none is refused", func(t *testing.T) {
+		token, _ := SignHS256(validClaims(), secret)
+		parts := strings.Split(token, ".")
+		_, err := v.Verify("eyJhbGciOiJub25lIn0." + parts[1] + ".")
+		assert.Equal(t, ErrUnsupportedAlg, err)
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		_, err := v.Verify("not-a-token")
+		assert.Equal(t, ErrMalformedToken, err)
+	})
+}
+
+func Test_VerifyRS256WithKeyFile(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "jwt_public.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	assert.Nil(t, err)
+
+	publicKey, err := LoadRSAPublicKey(path)
+	assert.Nil(t, err)
+
+	v, err := NewVerifier(WithRSAPublicKey(publicKey))
+	assert.Nil(t, err)
+
+	token, err := SignRS256(validClaims(), key)
+	assert.Nil(t, err)
+
+	claims, err := v.Verify(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "staff", claims.Role)
+
+	// HS256 is refused when no HMAC secret is configured, even if signed with the public key bytes
+	hsToken, _ := SignHS256(validClaims(), der)
+	_, err = v.Verify(hsToken)
+	assert.Equal(t, ErrUnsupportedAlg, err)
+}
+
+func Test_NewVerifierRequiresKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewVerifier()
+	assert.NotNil(t, err)
+}
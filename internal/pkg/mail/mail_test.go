@@ -0,0 +1,160 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal SMTP server accepting one message per connection, enough to test the sender against
+type smtpStandIn struct {
+	listener net.Listener
+	received chan string
+	reject   bool //answer RCPT TO with a permanent failure
+}
+
+func newSMTPStandIn(t *testing.T, reject bool) *smtpStandIn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := &smtpStandIn{listener: listener, received: make(chan string, 1), reject: reject}
+	t.Cleanup(func() { listener.Close() })
+
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 stand-in")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			if s.reject {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.received <- data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func Test_SMTPSend(t *testing.T) {
+	t.Parallel()
+
+	standIn := newSMTPStandIn(t, false)
+	sender, err := NewSMTP(Config{Host: "127.0.0.1", Port: standIn.port(), From: "loans@example.com", Timeout: 5 * time.Second})
+	assert.Nil(t, err)
+
+	err = sender.Send(context.Background(), Message{
+		To:      "investor@example.com",
+		Subject: "Your loan is fully funded – agreement inside",
+		Text:    "Download your agreement letter.",
+		HTML:    "<p>Download your <a href=\"https://loans.example.com/agreement\">agreement letter</a>.</p>",
+	})
+	assert.Nil(t, err)
+
+	raw := <-standIn.received
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "loans@example.com", msg.Header.Get("From"))
+	assert.Equal(t, "investor@example.com", msg.Header.Get("To"))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Your loan is fully funded – agreement inside", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		content, _ := io.ReadAll(part) //quoted-printable is decoded by the multipart reader
+		bodies = append(bodies, part.Header.Get("Content-Type")+"|"+string(content))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8|Download your agreement letter.",
+		"text/html; charset=utf-8|<p>Download your <a href=\"https://loans.example.com/agreement\">agreement letter</a>.</p>",
+	}, bodies)
+}
+
+func Test_SMTPSendRejected(t *testing.T) {
+	t.Parallel()
+
+	standIn := newSMTPStandIn(t, true)
+	sender, err := NewSMTP(Config{Host: "127.0.0.1", Port: standIn.port(), From: "loans@example.com"})
+	assert.Nil(t, err)
+
+	err = sender.Send(context.Background(), Message{To: "nobody@example.com", Subject: "hi", Text: "hi"})
+	assert.ErrorContains(t, err, "550")
+
+	err = sender.Send(context.Background(), Message{To: "a@example.com\r\nBcc: b@example.com", Subject: "hi", Text: "hi"})
+	assert.NotNil(t, err)
+
+	_, err = NewSMTP(Config{Host: "127.0.0.1:" + strconv.Itoa(standIn.port())})
+	assert.NotNil(t, err)
+}
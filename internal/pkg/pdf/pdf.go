@@ -0,0 +1,151 @@
+// Package pdf writes plain text documents as PDF 1.4: A4 pages, a single Helvetica font, lines wrapped to the page.
+// It covers generated letters and statements, not arbitrary layouts.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 595 //A4 in points
+	pageHeight   = 842
+	margin       = 50
+	fontSize     = 10
+	leading      = 14
+	maxLineChars = 95 //what fits between the margins at fontSize
+	linesPerPage = (pageHeight - 2*margin) / leading
+)
+
+// Document collects lines of text, wrapped to the page width
+type Document struct {
+	lines []string
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// Text adds s, each of its lines becoming a paragraph wrapped at word boundaries. Characters outside printable ASCII,
+// which the standard font encoding may not cover, are replaced by '?'.
+func (d *Document) Text(s string) {
+	for _, paragraph := range strings.Split(s, "\n") {
+		d.lines = append(d.lines, wrap(sanitize(paragraph), maxLineChars)...)
+	}
+}
+
+// Bytes renders the document, a document without text still has one blank page
+func (d *Document) Bytes() []byte {
+	var pages [][]string
+	for start := 0; start < len(d.lines); start += linesPerPage {
+		end := min(start+linesPerPage, len(d.lines))
+		pages = append(pages, d.lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = [][]string{nil}
+	}
+
+	//objects: 1 catalog, 2 page tree, 3 font, then a page and its content stream for every page
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", //page tree, filled once the page objects are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := make([]string, len(pages))
+	for i, lines := range pages {
+		pageObj := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj)
+
+		stream := content(lines)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, pageObj+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// content is the page's text drawing operators, one line below the other from the top margin
+func content(lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) Tj T*\n", escape(line))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < ' ' || r > '~':
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// wrap splits s into lines of at most width characters, breaking words only when they are longer than a line
+func wrap(s string, width int) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var (
+		lines   []string
+		current string
+	)
+	for _, word := range words {
+		for len(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Document(t *testing.T) {
+	t.Parallel()
+
+	doc := New()
+	doc.Text("Loan Agreement (v1)\nBorrower: d149aaa5 \\ café")
+	out := doc.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), `(Loan Agreement \(v1\)) Tj`)
+	assert.Contains(t, string(out), `(Borrower: d149aaa5 \\ caf?) Tj`)
+
+	//startxref points at the cross reference table, whose entries point at the objects
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	assert.NotNil(t, m)
+	xref, _ := strconv.Atoi(string(m[1]))
+	assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1)
+	assert.Len(t, entries, 5)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")))
+	}
+}
+
+func Test_DocumentPages(t *testing.T) {
+	t.Parallel()
+
+	doc := New()
+	doc.Text(strings.Repeat("line\n", linesPerPage+1))
+	assert.Contains(t, string(doc.Bytes()), "/Count 2")
+
+	assert.Contains(t, string(New().Bytes()), "/Count 1")
+}
+
+func Test_Wrap(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{""}, wrap("   ", 10))
+	assert.Equal(t, []string{"the quick", "brown fox"}, wrap("the quick brown fox", 10))
+	assert.Equal(t, []string{"abcdefghij", "klm end"}, wrap("abcdefghijklm end", 10))
+}
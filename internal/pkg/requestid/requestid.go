@@ -0,0 +1,38 @@
+// Package requestid carries the ID of the API request being served in its context, so what the request changes can
+// be traced back to it. Work not started by a request, e.g. background jobs, has no request ID.
+package requestid
+
+import (
+	"context"
+)
+
+// Header is the header a client may send its own request ID in, the ID used is echoed back in it
+const Header = "X-Request-ID"
+
+// MaxLength is the longest request ID accepted from a client
+const MaxLength = 128
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, empty when there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Valid reports whether id can be used as given by a client: not empty, not too long and printable ASCII only
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LocalPutAndOpen(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewLocal(t.TempDir()+"/uploads", "http://localhost:8080", []byte("secret"))
+	assert.Nil(t, err)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	key := "agreements/0a1b2c.pdf"
+	assert.Nil(t, store.Put(ctx, key, "application/pdf", []byte("%PDF-1.4")))
+	//same content, same key: storing it again is harmless
+	assert.Nil(t, store.Put(ctx, key, "application/pdf", []byte("%PDF-1.4")))
+
+	signed, err := store.SignedURL(ctx, key, 15*time.Minute)
+	assert.Nil(t, err)
+
+	u, err := url.Parse(signed)
+	assert.Nil(t, err)
+	assert.Equal(t, LocalPathPrefix+key, u.Path)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	assert.Equal(t, now.Add(15*time.Minute).Unix(), expires)
+	signature := u.Query().Get("signature")
+
+	f, err := store.Open(key, expires, signature)
+	assert.Nil(t, err)
+	content, _ := io.ReadAll(f)
+	f.Close()
+	assert.Equal(t, []byte("%PDF-1.4"), content)
+
+	_, err = store.Open(key, expires+1, signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = store.Open("agreements/other.pdf", expires, signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	now = now.Add(time.Hour)
+	_, err = store.Open(key, expires, signature)
+	assert.Equal(t, ErrURLExpired, err)
+}
+
+func Test_LocalMissingDocument(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewLocal(t.TempDir(), "", []byte("secret"))
+	assert.Nil(t, err)
+
+	signed, _ := store.SignedURL(context.Background(), "approvals/missing.jpg", time.Minute)
+	u, _ := url.Parse(signed)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+
+	_, err = store.Open(strings.TrimPrefix(u.Path, LocalPathPrefix), expires, u.Query().Get("signature"))
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_ValidKey(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, validKey("agreements/0a1b2c.pdf"))
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", ".", "a\\b"} {
+		assert.False(t, validKey(key), key)
+	}
+
+	_, err := NewLocal(t.TempDir(), "", nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,24 @@
+// Package storage keeps uploaded and generated documents, either on the local filesystem or in an S3-compatible
+// object store, and hands out time limited signed URLs to download them.
+package storage
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid document key")
+	ErrInvalidSignature = errors.New("invalid document signature")
+	ErrURLExpired       = errors.New("document URL is expired")
+	ErrNotFound         = errors.New("document not found")
+)
+
+// validKey accepts slash separated relative keys that cannot escape the store, e.g. agreements/<sha256>.pdf
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../")
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	idempotencyRepo struct {
+		*postgres.Postgres
+	}
+)
+
+func NewIdempotencyRepo(pg *postgres.Postgres) *idempotencyRepo {
+	return &idempotencyRepo{pg}
+}
+
+func (r *idempotencyRepo) ClaimKey(ctx context.Context, record entity.IdempotencyRecord, expiredBefore time.Time) (bool, error) {
+
+	//an expired record is overwritten in place, a live one is left alone and nothing is returned
+	query := `INSERT INTO idempotency_key (subject, idempotency_key, request_hash, status, created_at) VALUES ($1, $2, $3, $4, now())
+	ON CONFLICT (subject, idempotency_key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = EXCLUDED.status,
+	response_status = NULL, response_body = NULL, created_at = EXCLUDED.created_at, completed_at = NULL
+	WHERE idempotency_key.created_at < $5
+	RETURNING created_at`
+
+	var createdAt time.Time
+	err := r.DB.QueryRowContext(ctx, query, record.Subject, record.Key, record.RequestHash, record.Status, expiredBefore).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *idempotencyRepo) GetRecord(ctx context.Context, subject, key string) (*entity.IdempotencyRecord, error) {
+
+	var (
+		record         entity.IdempotencyRecord
+		responseStatus sql.NullInt64
+	)
+
+	query := `SELECT subject, idempotency_key, request_hash, status, response_status, response_body, created_at
+	FROM idempotency_key WHERE subject = $1 AND idempotency_key = $2`
+	err := r.DB.QueryRowContext(ctx, query, subject, key).Scan(&record.Subject, &record.Key, &record.RequestHash, &record.Status,
+		&responseStatus, &record.ResponseBody, &record.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	record.ResponseStatus = int(responseStatus.Int64)
+	return &record, nil
+}
+
+func (r *idempotencyRepo) CompleteRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	query := `UPDATE idempotency_key SET status = $3, response_status = $4, response_body = $5, completed_at = now()
+	WHERE subject = $1 AND idempotency_key = $2`
+	_, err := r.DB.ExecContext(ctx, query, record.Subject, record.Key, record.Status, record.ResponseStatus, record.ResponseBody)
+	return err
+}
+
+func (r *idempotencyRepo) DeleteRecord(ctx context.Context, subject, key string) error {
+	query := `DELETE FROM idempotency_key WHERE subject = $1 AND idempotency_key = $2`
+	_, err := r.DB.ExecContext(ctx, query, subject, key)
+	return err
+}
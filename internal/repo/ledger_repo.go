@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	ledgerRepo struct {
+		*postgres.Postgres
+	}
+)
+
+var errUnbalancedTransaction = errors.New("ledger transaction does not balance")
+
+func NewLedgerRepo(pg *postgres.Postgres) *ledgerRepo {
+	return &ledgerRepo{pg}
+}
+
+// insertLedgerTransaction appends txn to the ledger within tx, so money movements are committed together with
+// the business operation they belong to. The database also refuses an unbalanced transaction at commit.
+func insertLedgerTransaction(ctx context.Context, tx *sql.Tx, txn entity.LedgerTransaction) error {
+	if !txn.Balanced() {
+		return errUnbalancedTransaction
+	}
+
+	query := `INSERT INTO ledger_transaction (transaction_id, kind, loan_id, reference_id, created_at) VALUES ($1, $2, $3, $4, now())`
+	_, err := tx.ExecContext(ctx, query, txn.ID, txn.Kind, nullUUID(txn.LoanID), nullUUID(txn.ReferenceID))
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO ledger_entry (transaction_id, account_type, account_owner, side, amount, currency, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, now())`
+	for _, e := range txn.Entries {
+		_, err = tx.ExecContext(ctx, query, txn.ID, e.Account.Type, nullUUID(e.Account.OwnerID), e.Side, e.Amount.Amount, e.Amount.Currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func (r *ledgerRepo) ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error) {
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("account_type = $%d", len(args)))
+	}
+	if filter.OwnerID != uuid.Nil {
+		args = append(args, filter.OwnerID)
+		conditions = append(conditions, fmt.Sprintf("account_owner = $%d", len(args)))
+	}
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		conditions = append(conditions, fmt.Sprintf("currency = $%d", len(args)))
+	}
+
+	query := `SELECT account_type, account_owner, currency,
+	COALESCE(SUM(amount) FILTER (WHERE side = 'debit'), 0), COALESCE(SUM(amount) FILTER (WHERE side = 'credit'), 0)
+	FROM ledger_entry`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " GROUP BY account_type, account_owner, currency ORDER BY account_type, account_owner, currency"
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []entity.AccountBalance{}
+	for rows.Next() {
+		var (
+			balance  entity.AccountBalance
+			owner    uuid.NullUUID
+			currency string
+		)
+
+		err := rows.Scan(&balance.Type, &owner, &currency, &balance.Debits.Amount, &balance.Credits.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		balance.OwnerID = owner.UUID
+		balance.Debits.Currency = currency
+		balance.Credits.Currency = currency
+		balances = append(balances, balance)
+	}
+
+	return balances, rows.Err()
+}
+
+func (r *ledgerRepo) LedgerTotals(ctx context.Context) ([]entity.LedgerTotals, error) {
+
+	query := `SELECT currency, COALESCE(SUM(amount) FILTER (WHERE side = 'debit'), 0), COALESCE(SUM(amount) FILTER (WHERE side = 'credit'), 0)
+	FROM ledger_entry GROUP BY currency ORDER BY currency`
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []entity.LedgerTotals{}
+	for rows.Next() {
+		var t entity.LedgerTotals
+		if err := rows.Scan(&t.Currency, &t.Debits, &t.Credits); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *ledgerRepo) ListUnbalancedTransactions(ctx context.Context) ([]uuid.UUID, error) {
+
+	query := `SELECT transaction_id FROM ledger_entry GROUP BY transaction_id, currency
+	HAVING SUM(CASE WHEN side = 'debit' THEN amount ELSE -amount END) <> 0 ORDER BY transaction_id`
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/requestid"
+)
+
+// rowQuerier is the database or a transaction
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// loadLoan reads the full loan row with its approval. With forUpdate the loan row stays locked until the end of the
+// transaction q is.
+func loadLoan(ctx context.Context, q rowQuerier, loanID uuid.UUID, forUpdate bool) (*entity.Loan, error) {
+
+	var (
+		loan             entity.Loan
+		agreementLetter  sql.NullString
+		updatedAt        sql.NullTime
+		disburseAt       sql.NullTime
+		fundingDeadline  sql.NullTime
+		pictureProof     sql.NullString
+		fieldValidatorID uuid.NullUUID
+		approvedBy       uuid.NullUUID
+		approvalDate     sql.NullTime
+	)
+
+	query := `SELECT l.loan_id, l.borrower_id, l.principal_amount, l.currency, l.interest_rate_bps, l.tenor_months, l.repayment_method, l.agreement_letter, l.status, l.created_at, l.updated_at, l.disburse_at, l.funding_deadline,
+	` + remainingAmountColumn + `, a.picture_proof, a.field_validator_id, a.approved_by, a.approval_date
+	FROM loan l LEFT JOIN loan_approval a ON a.loan_id = l.loan_id WHERE l.loan_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF l`
+	}
+	err := q.QueryRowContext(ctx, query, loanID).Scan(&loan.ID, &loan.BorrowerID,
+		&loan.PrincipalAmount.Amount, &loan.PrincipalAmount.Currency, &loan.InterestRate, &loan.TenorMonths, &loan.RepaymentMethod, &agreementLetter, &loan.Status, &loan.CreatedAt,
+		&updatedAt, &disburseAt, &fundingDeadline, &loan.RemainingAmount.Amount, &pictureProof, &fieldValidatorID, &approvedBy, &approvalDate)
+
+	if err == sql.ErrNoRows {
+		return nil, apperror.ErrLoanNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	loan.AgreementLetter = agreementLetter.String
+	loan.UpdatedAt = updatedAt.Time
+	loan.DisburseAt = disburseAt.Time
+	loan.FundingDeadline = fundingDeadline.Time
+	loan.RemainingAmount.Currency = loan.PrincipalAmount.Currency
+
+	if pictureProof.Valid {
+		loan.Approval = &entity.LoanApproval{
+			LoanID:           loan.ID,
+			PictureProof:     pictureProof.String,
+			FieldValidatorID: fieldValidatorID.UUID,
+			ApprovedBy:       approvedBy.UUID,
+			ApprovalDate:     approvalDate.Time,
+		}
+	}
+
+	return &loan, nil
+}
+
+// loanAudit writes a change of a loan to loan_status_history, with the full loan row before and after the change
+type loanAudit struct {
+	loanID uuid.UUID
+	before *entity.Loan
+}
+
+// beginLoanAudit locks the loan within tx and snapshots it before it is changed. The lock is held until tx ends, so
+// the snapshot is exactly what the change starts from.
+func beginLoanAudit(ctx context.Context, tx *sql.Tx, loanID uuid.UUID) (*loanAudit, error) {
+	before, err := loadLoan(ctx, tx, loanID, true)
+	if err != nil {
+		return nil, err
+	}
+	return &loanAudit{loanID: loanID, before: before}, nil
+}
+
+// record snapshots the loan again, once the change has been applied within tx, and writes the history record
+func (a *loanAudit) record(ctx context.Context, tx *sql.Tx, actor entity.Actor, reason string) error {
+	after, err := loadLoan(ctx, tx, a.loanID, false)
+	if err != nil {
+		return err
+	}
+	return insertLoanHistory(ctx, tx, a.loanID, a.before, after, actor, reason)
+}
+
+// insertLoanHistory writes a history record of the loan, made by actor within the API request carried by ctx if any.
+// before is nil for the record of a new loan.
+func insertLoanHistory(ctx context.Context, tx *sql.Tx, loanID uuid.UUID, before, after any, actor entity.Actor, reason string) error {
+	requestID := requestid.FromContext(ctx)
+
+	query := `INSERT INTO loan_status_history (loan_id, before, after, updated_by, actor_type, request_id, reason, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, now())`
+	_, err := tx.ExecContext(ctx, query, loanID, before, after, nullUUID(actor.ID), actor.Type,
+		sql.NullString{String: requestID, Valid: requestID != ""}, reason)
+	return err
+}
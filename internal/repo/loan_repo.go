@@ -3,12 +3,14 @@ package repo
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
 	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/ferdikurniawan/loan-service/internal/entity"
 )
@@ -19,6 +21,9 @@ type (
 	}
 )
 
+// remainingAmountColumn computes, for loan aliased as l, the principal not yet covered by investments
+const remainingAmountColumn = `l.principal_amount - COALESCE((SELECT SUM(i.amount) FROM loan_investment i WHERE i.loan_id = l.loan_id AND i.status = 'active'), 0) AS remaining_amount`
+
 func NewLoanRepo(pg *postgres.Postgres) *loanRepo {
 	return &loanRepo{pg}
 }
@@ -26,22 +31,44 @@ func NewLoanRepo(pg *postgres.Postgres) *loanRepo {
 func (r *loanRepo) InsertLoan(ctx context.Context, loan *entity.Loan) (*entity.Loan, error) {
 	var result entity.Loan
 
-	query := `INSERT INTO loan (loan_id, borrower_id, principal_amount, interest_rate, status, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING loan_id, created_at, updated_at`
-	err := r.DB.QueryRowContext(ctx, query, loan.ID, loan.BorrowerID, loan.PrincipalAmount, loan.InterestRate, "proposed", "now()", "now()").Scan(&result.ID, &result.CreatedAt, &result.UpdatedAt)
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO loan (loan_id, borrower_id, principal_amount, currency, interest_rate_bps, tenor_months, repayment_method, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING loan_id, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, loan.ID, loan.BorrowerID, loan.PrincipalAmount.Amount, loan.PrincipalAmount.Currency,
+		loan.InterestRate, loan.TenorMonths, loan.RepaymentMethod, entity.LoanStatusProposed, "now()", "now()").Scan(&result.ID, &result.CreatedAt, &result.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	//the first record of a loan has no before snapshot
+	created, err := loadLoan(ctx, tx, result.ID, false)
 	if err != nil {
 		return nil, err
 	}
+	if err := insertLoanHistory(ctx, tx, result.ID, nil, created, entity.BorrowerActor(loan.BorrowerID), "loan proposed"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	result.BorrowerID = loan.BorrowerID
 	result.PrincipalAmount = loan.PrincipalAmount
 	result.InterestRate = loan.InterestRate
-	result.Status = "proposed"
+	result.TenorMonths = loan.TenorMonths
+	result.RepaymentMethod = loan.RepaymentMethod
+	result.Status = entity.LoanStatusProposed
 
 	return &result, nil
 }
 
-func (r *loanRepo) UpdateLoanStatus(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error {
+func (r *loanRepo) UpdateLoanStatus(ctx context.Context, change entity.LoanStatusChange) error {
 
 	//wrap queries within one transaction since there are multiple dependent ops:
 	//update status and loan log table record insertion
@@ -51,53 +78,264 @@ func (r *loanRepo) UpdateLoanStatus(ctx context.Context, loan *entity.Loan, staf
 	}
 	defer tx.Rollback()
 
-	var updatedAt time.Time
-	var currentStatus string
-	query := `SELECT updated_at, status FROM loan WHERE loan_id = $1` //get loan detail, esp the updated_at to achieve optimistic locking
-	err = tx.QueryRowContext(ctx, query, loan.ID).Scan(&updatedAt, &currentStatus)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("loan not found")
-	} else if err != nil {
+	audit, err := beginLoanAudit(ctx, tx, change.LoanID)
+	if err != nil {
 		return err
 	}
+	current := audit.before
+
+	//the transition has been validated against change.From, make sure nobody moved the loan in the meantime
+	if current.Status != change.From {
+		return apperror.ErrLoanConcurrentUpdate
+	}
 
-	updateTime := time.Now()
-	queryUpdate := `UPDATE loan SET status = $4, updated_at = $3 WHERE loan_id = $1 AND updated_at = $2`
+	queryUpdate := `UPDATE loan SET status = $2, updated_at = $3, funding_deadline = COALESCE($4, funding_deadline) WHERE loan_id = $1`
 
-	res, err := tx.ExecContext(ctx, queryUpdate, loan.ID, updatedAt, updateTime, loan.Status)
+	fundingDeadline := sql.NullTime{Time: change.FundingDeadline, Valid: !change.FundingDeadline.IsZero()}
+	_, err = tx.ExecContext(ctx, queryUpdate, change.LoanID, change.To, time.Now(), fundingDeadline)
 	if err != nil {
 		return err
 	}
 
-	affected, err := res.RowsAffected()
+	if change.Approval != nil {
+		queryApproval := `INSERT INTO loan_approval (loan_id, picture_proof, field_validator_id, approved_by, approval_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+		_, err = tx.ExecContext(ctx, queryApproval, change.LoanID, change.Approval.PictureProof, change.Approval.FieldValidatorID,
+			change.Approval.ApprovedBy, change.Approval.ApprovalDate, "now()")
+		if err != nil {
+			return err
+		}
+	}
+
+	//changes made by the system itself, e.g. expiry, have no staff behind them
+	if err := audit.record(ctx, tx, change.Actor(), change.Reason); err != nil {
+		return err
+	}
+
+	event := entity.LoanStatusChanged{
+		LoanID:          change.LoanID,
+		BorrowerID:      current.BorrowerID,
+		PrincipalAmount: current.PrincipalAmount,
+		From:            current.Status,
+		To:              change.To,
+		Reason:          change.Reason,
+	}
+	if change.UpdatedBy != uuid.Nil {
+		event.ChangedBy = &change.UpdatedBy
+	}
+	if !change.FundingDeadline.IsZero() {
+		event.FundingDeadline = &change.FundingDeadline
+	}
+	if err := insertLoanEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	//a loan that will not be funded any more gives the pledged funds back to its investors
+	if change.To == entity.LoanStatusCancelled || change.To == entity.LoanStatusExpired {
+		if err := releaseHolds(ctx, tx, change.LoanID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddLoanInvestments pledges investment to the loan. checkInvestor, when set, is given the investor's totals before
+// the pledge, read while the loan and the investor's wallet are locked so that concurrent pledges of the investor
+// cannot all pass it; its error refuses the pledge. It reports whether the pledge fully invested the loan.
+func (r *loanRepo) AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment, checkInvestor func(entity.InvestorTotals) error) (bool, error) {
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	//1. Lock the loan and check its status & remaining amount
+	audit, err := beginLoanAudit(ctx, tx, investment.LoanID)
+	if err != nil {
+		return false, err
+	}
+	current := audit.before
+	if current.Status != entity.LoanStatusApproved {
+		return false, apperror.ErrInvalidTransition.Withf("loan is not approved yet / has reach principal amount")
+	}
+	//the loan may not have been picked up by the expiry job yet
+	if !current.FundingDeadline.IsZero() && !time.Now().Before(current.FundingDeadline) {
+		return false, apperror.ErrFundingDeadlinePassed
+	}
+
+	if !investment.Amount.SameCurrency(current.PrincipalAmount) {
+		return false, apperror.ErrCurrencyMismatch
+	}
+
+	remaining := current.RemainingAmount.Amount
+	if investment.Amount.Amount > remaining {
+		return false, apperror.ErrInvestmentExceedsRemaining
+	}
+
+	if checkInvestor != nil {
+		totals, err := lockInvestorTotals(ctx, tx, investment)
+		if err != nil {
+			return false, err
+		}
+		if err := checkInvestor(totals); err != nil {
+			return false, err
+		}
+	}
+
+	//2. Insert the investment, reserve the pledged amount on the investor's wallet and move it into the loan escrow
+	investment.ID = uuid.New()
+	query := `INSERT INTO loan_investment (loan_investment_id, loan_id, investor_id, amount, currency, invested_at)
+	VALUES ($1, $2, $3, $4, $5, 'now()')`
+	_, err = tx.ExecContext(ctx, query, investment.ID, investment.LoanID, investment.InvestorID, investment.Amount.Amount, investment.Amount.Currency)
+	if err != nil {
+		return false, err
+	}
+
+	if err := holdFunds(ctx, tx, investment); err != nil {
+		return false, err
+	}
+
+	if err := insertLedgerTransaction(ctx, tx, entity.PledgeTransaction(investment)); err != nil {
+		return false, err
+	}
+
+	//3. Update Loan status if invested fund reached principal loan amount
+	fullyInvested := investment.Amount.Amount == remaining
+	status := current.Status
+	if fullyInvested {
+		status = entity.LoanStatusInvested
+	}
+	query = `UPDATE loan SET status = $3, updated_at = $2 WHERE loan_id = $1`
+	_, err = tx.ExecContext(ctx, query, investment.LoanID, time.Now(), status)
+	if err != nil {
+		return false, err
+	}
+
+	//4. Add Loan History Log Record, every pledge changes what the loan still needs
+	reason := fmt.Sprintf("investment %s of %s pledged by investor %s", investment.ID, investment.Amount.String(), investment.InvestorID)
+	if fullyInvested {
+		reason += ", principal amount fully invested"
+	}
+	if err := audit.record(ctx, tx, entity.InvestorActor(investment.InvestorID), reason); err != nil {
+		return false, err
+	}
+
+	if fullyInvested {
+		err = insertLoanEvent(ctx, tx, entity.LoanStatusChanged{
+			LoanID:          investment.LoanID,
+			BorrowerID:      current.BorrowerID,
+			PrincipalAmount: current.PrincipalAmount,
+			From:            current.Status,
+			To:              entity.LoanStatusInvested,
+			Reason:          "principal amount fully invested",
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if err := insertLoanInvestedNotifications(ctx, tx, investment.LoanID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return fullyInvested, nil
+}
+
+func (r *loanRepo) WithdrawInvestment(ctx context.Context, withdrawal *entity.InvestmentWithdrawal) error {
+
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if affected == 0 {
-		return fmt.Errorf("loan has been updated by another staff")
+	//1. Lock the loan, the same lock AddLoanInvestments takes, so the remaining amount cannot move meanwhile
+	audit, err := beginLoanAudit(ctx, tx, withdrawal.LoanID)
+	if err != nil {
+		return err
+	}
+	current := audit.before
+	if current.Status != entity.LoanStatusApproved {
+		return apperror.ErrInvalidTransition.Withf("pledges can only be withdrawn while the loan is approved, it is %s", current.Status)
 	}
 
-	loanPrev := entity.Loan{
-		ID:        loan.ID,
-		Status:    currentStatus,
-		UpdatedAt: updatedAt,
+	//2. Mark the investment withdrawn, only its own investor may do so
+	query := `UPDATE loan_investment SET status = $4, withdrawn_at = now()
+	WHERE loan_investment_id = $1 AND loan_id = $2 AND investor_id = $3 AND status = $5
+	RETURNING amount, currency, withdrawn_at`
+	err = tx.QueryRowContext(ctx, query, withdrawal.InvestmentID, withdrawal.LoanID, withdrawal.InvestorID,
+		entity.InvestmentStatusWithdrawn, entity.InvestmentStatusActive).Scan(&withdrawal.Amount.Amount, &withdrawal.Amount.Currency, &withdrawal.WithdrawnAt)
+	if err == sql.ErrNoRows {
+		return apperror.ErrInvestmentNotFound
+	} else if err != nil {
+		return err
 	}
-	loanAfter := loanPrev
-	loanAfter.Status = loan.Status
-	loanAfter.UpdatedAt = updateTime
 
-	queryLoanStatusHistory := `INSERT INTO loan_status_history (loan_id, before, after, updated_by, updated_at)
-	VALUES ($1, $2, $3, $4, $5)`
-	_, err = tx.Exec(queryLoanStatusHistory, loan.ID, loanPrev, loanAfter, staffID, "now()")
+	//3. Give the pledged amount back to the investor's wallet
+	if err := releaseInvestmentHold(ctx, tx, withdrawal.LoanID, withdrawal.InvestmentID); err != nil {
+		return err
+	}
+
+	//4. What the loan still needs grows by the withdrawn amount, log the withdrawal in the loan history
+	withdrawal.RemainingAmount = entity.NewMoney(current.RemainingAmount.Amount+withdrawal.Amount.Amount, current.PrincipalAmount.Currency)
+
+	query = `UPDATE loan SET updated_at = $2 WHERE loan_id = $1`
+	_, err = tx.ExecContext(ctx, query, withdrawal.LoanID, time.Now())
 	if err != nil {
 		return err
 	}
 
+	reason := fmt.Sprintf("investment %s of %s withdrawn by investor %s", withdrawal.InvestmentID, withdrawal.Amount.String(), withdrawal.InvestorID)
+	if err := audit.record(ctx, tx, entity.InvestorActor(withdrawal.InvestorID), reason); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (r *loanRepo) AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment) error {
+// ListLoanHistory returns the loan's history records in the order they were written
+func (r *loanRepo) ListLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryRecord, error) {
+
+	query := `SELECT loan_status_history_id, loan_id, before, after, updated_by, COALESCE(actor_type, ''), COALESCE(request_id, ''),
+	COALESCE(reason, ''), updated_at
+	FROM loan_status_history WHERE loan_id = $1 ORDER BY loan_status_history_id`
+	rows, err := r.DB.QueryContext(ctx, query, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []entity.LoanHistoryRecord{}
+	for rows.Next() {
+		var (
+			record        entity.LoanHistoryRecord
+			before, after []byte
+			updatedAt     sql.NullTime
+		)
+		err := rows.Scan(&record.ID, &record.LoanID, &before, &after, &record.UpdatedBy, &record.ActorType, &record.RequestID,
+			&record.Reason, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		record.Before, record.After = before, after
+		record.UpdatedAt = updatedAt.Time
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (r *loanRepo) GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error) {
+	return loadLoan(ctx, r.DB, loanID, false)
+}
+
+func (r *loanRepo) DisburseLoan(ctx context.Context, loan *entity.Loan, schedule []entity.LoanInstalment, staffID uuid.UUID) error {
 
 	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
@@ -105,97 +343,381 @@ func (r *loanRepo) AddLoanInvestments(ctx context.Context, investment entity.Loa
 	}
 	defer tx.Rollback()
 
-	//1. Check amount & status of the loan
-	var amount int64
-	var status string
-	query := `SELECT principal_amount, status FROM loan where loan_id = $1 FOR UPDATE`
-	err = tx.QueryRowContext(ctx, query, investment.LoanID).Scan(&amount, &status)
+	//only an invested loan can be disbursed, the status guard protects against concurrent disbursement
+	audit, err := beginLoanAudit(ctx, tx, loan.ID)
+	if err != nil {
+		return err
+	}
+	current := audit.before
+	if current.Status != entity.LoanStatusInvested {
+		return apperror.ErrLoanConcurrentUpdate
+	}
+
+	query := `UPDATE loan SET status = $1, agreement_letter = $2, disburse_at = $3, updated_at = $5 WHERE loan_id = $4`
+	_, err = tx.ExecContext(ctx, query, loan.Status, loan.AgreementLetter, loan.DisburseAt, loan.ID, "now()")
+	if err != nil {
+		return err
+	}
+
+	if err := insertLedgerTransaction(ctx, tx, entity.DisbursementTransaction(loan.ID, current.BorrowerID, current.PrincipalAmount)); err != nil {
+		return err
+	}
+
+	if err := captureHolds(ctx, tx, loan.ID); err != nil {
+		return err
+	}
+
+	if err := audit.record(ctx, tx, entity.StaffActor(staffID), "loan disbursed to borrower"); err != nil {
+		return err
+	}
+
+	err = insertLoanEvent(ctx, tx, entity.LoanStatusChanged{
+		LoanID:          loan.ID,
+		BorrowerID:      current.BorrowerID,
+		PrincipalAmount: current.PrincipalAmount,
+		From:            current.Status,
+		To:              loan.Status,
+		Reason:          "loan disbursed to borrower",
+		ChangedBy:       &staffID,
+		DisburseAt:      &loan.DisburseAt,
+	})
 	if err != nil {
 		return err
 	}
-	if status != "approved" {
-		return errors.New("loan is not approved yet / has reach principal amount")
+
+	queryInstalment := `INSERT INTO loan_instalment (loan_id, instalment_number, due_date, principal_amount, interest_amount, outstanding_principal, currency)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	for _, inst := range schedule {
+		_, err = tx.ExecContext(ctx, queryInstalment, loan.ID, inst.Number, inst.DueDate, inst.Principal.Amount, inst.Interest.Amount,
+			inst.OutstandingPrincipal.Amount, inst.Principal.Currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *loanRepo) ListLoans(ctx context.Context, filter entity.LoanFilter) ([]entity.Loan, error) {
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	//where appends a condition whose single placeholder is numbered after the args collected so far
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.Statuses) > 0 {
+		where("l.status::text = ANY($%d)", pq.Array(filter.Statuses))
+	}
+	if filter.BorrowerID != uuid.Nil {
+		where("l.borrower_id = $%d", filter.BorrowerID)
+	}
+	if filter.Currency != "" {
+		where("l.currency = $%d", filter.Currency)
+	}
+	if filter.MinPrincipal != nil {
+		where("l.principal_amount >= $%d", *filter.MinPrincipal)
+	}
+	if filter.MaxPrincipal != nil {
+		where("l.principal_amount <= $%d", *filter.MaxPrincipal)
+	}
+	if filter.MinInterestRate != nil {
+		where("l.interest_rate_bps >= $%d", *filter.MinInterestRate)
+	}
+	if filter.MaxInterestRate != nil {
+		where("l.interest_rate_bps <= $%d", *filter.MaxInterestRate)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where("l.created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where("l.created_at < $%d", filter.CreatedBefore)
+	}
+	if !filter.DeadlineBefore.IsZero() {
+		where("l.funding_deadline < $%d", filter.DeadlineBefore)
 	}
 
-	//2. Get total investment
-	var totalInvested int64
-	query = `SELECT COALESCE(SUM(amount), 0) FROM loan_investment where loan_id = $1`
-	err = tx.QueryRowContext(ctx, query, investment.LoanID).Scan(&totalInvested)
+	//keyset pagination: continue strictly after the (created_at, loan_id) of the previous page's last row
+	order, comparison := "DESC", "<"
+	if filter.Ascending {
+		order, comparison = "ASC", ">"
+	}
+	if filter.AfterID != uuid.Nil {
+		args = append(args, filter.AfterCreatedAt, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("(l.created_at, l.loan_id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	query := `SELECT l.loan_id, l.borrower_id, l.principal_amount, l.currency, l.interest_rate_bps, l.tenor_months, l.repayment_method, l.agreement_letter, l.status, l.created_at, l.updated_at, l.disburse_at, l.funding_deadline,
+	` + remainingAmountColumn + ` FROM loan l`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY l.created_at %s, l.loan_id %s LIMIT $%d", order, order, len(args))
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loans := []entity.Loan{}
+	for rows.Next() {
+		var (
+			loan            entity.Loan
+			agreementLetter sql.NullString
+			updatedAt       sql.NullTime
+			disburseAt      sql.NullTime
+			fundingDeadline sql.NullTime
+		)
+
+		err := rows.Scan(&loan.ID, &loan.BorrowerID, &loan.PrincipalAmount.Amount, &loan.PrincipalAmount.Currency, &loan.InterestRate,
+			&loan.TenorMonths, &loan.RepaymentMethod, &agreementLetter, &loan.Status, &loan.CreatedAt, &updatedAt, &disburseAt, &fundingDeadline,
+			&loan.RemainingAmount.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		loan.AgreementLetter = agreementLetter.String
+		loan.UpdatedAt = updatedAt.Time
+		loan.DisburseAt = disburseAt.Time
+		loan.FundingDeadline = fundingDeadline.Time
+		loan.RemainingAmount.Currency = loan.PrincipalAmount.Currency
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+func (r *loanRepo) ListInvestments(ctx context.Context, filter entity.InvestmentFilter) ([]entity.InvestmentDetail, error) {
+
+	//withdrawn pledges take no part in the loan anymore
+	conditions := []string{"i.status = $1"}
+	args := []any{entity.InvestmentStatusActive}
+
+	if filter.LoanID != uuid.Nil {
+		args = append(args, filter.LoanID)
+		conditions = append(conditions, fmt.Sprintf("i.loan_id = $%d", len(args)))
+	}
+	if filter.InvestorID != uuid.Nil && filter.CoInvestors {
+		args = append(args, filter.InvestorID)
+		conditions = append(conditions, fmt.Sprintf("i.loan_id IN (SELECT loan_id FROM loan_investment WHERE investor_id = $%d AND status = $1)", len(args)))
+	} else if filter.InvestorID != uuid.Nil {
+		args = append(args, filter.InvestorID)
+		conditions = append(conditions, fmt.Sprintf("i.investor_id = $%d", len(args)))
+	}
+
+	query := `SELECT i.loan_investment_id, i.loan_id, i.investor_id, i.amount, i.currency, i.invested_at,
+	l.status, l.principal_amount, l.currency, l.interest_rate_bps, l.tenor_months, l.repayment_method, l.agreement_letter
+	FROM loan_investment i JOIN loan l ON l.loan_id = i.loan_id
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY i.invested_at, i.loan_investment_id`
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	investments := []entity.InvestmentDetail{}
+	for rows.Next() {
+		var inv entity.InvestmentDetail
+		err := rows.Scan(&inv.ID, &inv.LoanID, &inv.InvestorID, &inv.Amount.Amount, &inv.Amount.Currency, &inv.InvestedAt,
+			&inv.LoanStatus, &inv.PrincipalAmount.Amount, &inv.PrincipalAmount.Currency, &inv.InterestRate,
+			&inv.TenorMonths, &inv.RepaymentMethod, &inv.AgreementLetter)
+		if err != nil {
+			return nil, err
+		}
+		investments = append(investments, inv)
+	}
+
+	return investments, rows.Err()
+}
+
+func (r *loanRepo) ListInstalments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanInstalment, error) {
+
+	query := `SELECT loan_id, instalment_number, due_date, principal_amount, interest_amount, fee_amount, outstanding_principal,
+	principal_paid, interest_paid, fee_paid, currency
+	FROM loan_instalment WHERE loan_id = $1 ORDER BY instalment_number`
+	rows, err := r.DB.QueryContext(ctx, query, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instalments := []entity.LoanInstalment{}
+	for rows.Next() {
+		var (
+			inst     entity.LoanInstalment
+			currency string
+		)
+
+		err := rows.Scan(&inst.LoanID, &inst.Number, &inst.DueDate, &inst.Principal.Amount, &inst.Interest.Amount, &inst.Fee.Amount,
+			&inst.OutstandingPrincipal.Amount, &inst.PrincipalPaid.Amount, &inst.InterestPaid.Amount, &inst.FeePaid.Amount, &currency)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range []*entity.Money{&inst.Principal, &inst.Interest, &inst.Fee, &inst.OutstandingPrincipal,
+			&inst.PrincipalPaid, &inst.InterestPaid, &inst.FeePaid} {
+			m.Currency = currency
+		}
+		inst.Amount = entity.NewMoney(inst.Principal.Amount+inst.Interest.Amount+inst.Fee.Amount, currency)
+		instalments = append(instalments, inst)
+	}
+
+	return instalments, rows.Err()
+}
+
+func (r *loanRepo) RecordRepayment(ctx context.Context, record *entity.RepaymentRecord) error {
+
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	repayment := &record.Repayment
 
-	remaining := amount - totalInvested
-	if investment.Amount > remaining {
-		return errors.New("pledged fund exceeds the remaining loan value")
+	//1. Lock the loan and make sure the allocation was computed against its current version
+	audit, err := beginLoanAudit(ctx, tx, repayment.LoanID)
+	if err != nil {
+		return err
+	}
+	current := audit.before
+	if !current.UpdatedAt.Equal(record.LoanUpdatedAt) {
+		return apperror.ErrLoanConcurrentUpdate
 	}
 
-	//3. Insert the investment
-	query = `INSERT INTO loan_investment (loan_investment_id, loan_id, investor_id, amount, invested_at)
-	VALUES ($1, $2, $3, $4, 'now()')`
-	_, err = tx.ExecContext(ctx, query, uuid.New(), investment.LoanID, investment.InvestorID, investment.Amount)
+	//2. Insert the repayment
+	query := `INSERT INTO loan_repayment (repayment_id, loan_id, amount, currency, paid_at, recorded_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, now()) RETURNING created_at`
+	err = tx.QueryRowContext(ctx, query, repayment.ID, repayment.LoanID, repayment.Amount.Amount, repayment.Amount.Currency,
+		repayment.PaidAt, repayment.RecordedBy).Scan(&repayment.CreatedAt)
 	if err != nil {
 		return err
 	}
 
-	//4. Update Loan status if invested fund reached principal loan amount
-	if investment.Amount == remaining {
-		updatedTime := time.Now()
-		query = `UPDATE loan SET status = 'invested', updated_at = $2 WHERE loan_id = $1`
-		_, err = tx.ExecContext(ctx, query, investment.LoanID, updatedTime)
+	//3. Apply each allocation to its instalment
+	queryInstalment := `UPDATE loan_instalment SET principal_paid = $3, interest_paid = $4, fee_paid = $5
+	WHERE loan_id = $1 AND instalment_number = $2 AND principal_paid = $6 AND interest_paid = $7 AND fee_paid = $8`
+	for i, after := range record.After {
+		before := record.Before[i]
+
+		res, err := tx.ExecContext(ctx, queryInstalment, repayment.LoanID, after.Number, after.PrincipalPaid.Amount, after.InterestPaid.Amount,
+			after.FeePaid.Amount, before.PrincipalPaid.Amount, before.InterestPaid.Amount, before.FeePaid.Amount)
 		if err != nil {
 			return err
 		}
 
-		//4.1 Add Loan History Log Record
-		loanPrev := entity.Loan{
-			ID:     investment.LoanID,
-			Status: status,
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
 		}
-		loanAfter := loanPrev
-		loanAfter.Status = "invested"
-		loanAfter.UpdatedAt = updatedTime
+		if affected == 0 {
+			return apperror.ErrLoanConcurrentUpdate
+		}
+	}
 
-		queryLoanStatusHistory := `INSERT INTO loan_status_history (loan_id, before, after, updated_at)
-		VALUES ($1, $2, $3, $4)`
-		_, err = tx.Exec(queryLoanStatusHistory, investment.LoanID, loanPrev, loanAfter, "now()")
+	//4. Pass the repayment on to the investors
+	queryPayout := `INSERT INTO investor_payout (payout_id, repayment_id, loan_id, loan_investment_id, investor_id,
+	principal_amount, interest_amount, currency, paid_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())`
+	for _, payout := range repayment.Payouts {
+		_, err = tx.ExecContext(ctx, queryPayout, payout.ID, payout.RepaymentID, payout.LoanID, payout.InvestmentID, payout.InvestorID,
+			payout.Principal.Amount, payout.Interest.Amount, payout.Amount.Currency, payout.PaidAt)
 		if err != nil {
 			return err
 		}
 
+		if err := creditWallet(ctx, tx, payout.InvestorID, payout.Amount); err != nil {
+			return err
+		}
+	}
+
+	if err := insertLedgerTransaction(ctx, tx, entity.RepaymentTransaction(*repayment, current.BorrowerID)); err != nil {
+		return err
+	}
+
+	//5. Bump the loan version, moving it to repaying / closed when the repayment does so. The repayment is a single
+	//record in the loan history, its reason names the amounts allocated to each instalment.
+	nextStatus, reason := current.Status, repayment.Summary()
+	if change := record.StatusChange; change != nil {
+		nextStatus, reason = change.To, change.Reason+": "+reason
+	}
+
+	query = `UPDATE loan SET status = $3, updated_at = $2 WHERE loan_id = $1`
+	_, err = tx.ExecContext(ctx, query, repayment.LoanID, time.Now(), nextStatus)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.record(ctx, tx, entity.StaffActor(repayment.RecordedBy), reason); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *loanRepo) GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error) {
+func (r *loanRepo) ListPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error) {
 
 	var (
-		loan            entity.Loan
-		agreementLetter sql.NullString
-		updatedAt       sql.NullTime
-		disburseAt      sql.NullTime
+		conditions []string
+		args       []any
 	)
 
-	query := `SELECT loan_id, borrower_id, principal_amount, interest_rate, agreement_letter, status, created_at, updated_at, disburse_at
-	FROM loan WHERE loan_id = $1`
-	err := r.DB.QueryRowContext(ctx, query, loanID).Scan(&loan.ID, &loan.BorrowerID,
-		&loan.PrincipalAmount, &loan.InterestRate, &agreementLetter, &loan.Status, &loan.CreatedAt,
-		&updatedAt, &disburseAt)
+	if filter.InvestorID != uuid.Nil {
+		args = append(args, filter.InvestorID)
+		conditions = append(conditions, fmt.Sprintf("investor_id = $%d", len(args)))
+	}
+	if filter.LoanID != uuid.Nil {
+		args = append(args, filter.LoanID)
+		conditions = append(conditions, fmt.Sprintf("loan_id = $%d", len(args)))
+	}
+
+	query := `SELECT payout_id, repayment_id, loan_id, loan_investment_id, investor_id, principal_amount, interest_amount, currency, paid_at
+	FROM investor_payout`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY paid_at, created_at, payout_id"
 
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	payouts := []entity.InvestorPayout{}
+	for rows.Next() {
+		var (
+			payout   entity.InvestorPayout
+			currency string
+		)
 
-	loan.AgreementLetter = agreementLetter.String
-	loan.UpdatedAt = updatedAt.Time
-	loan.DisburseAt = disburseAt.Time
+		err := rows.Scan(&payout.ID, &payout.RepaymentID, &payout.LoanID, &payout.InvestmentID, &payout.InvestorID,
+			&payout.Principal.Amount, &payout.Interest.Amount, &currency, &payout.PaidAt)
+		if err != nil {
+			return nil, err
+		}
 
-	return &loan, err
+		payout.Principal.Currency = currency
+		payout.Interest.Currency = currency
+		payout.Amount = entity.NewMoney(payout.Principal.Amount+payout.Interest.Amount, currency)
+		payouts = append(payouts, payout)
+	}
 
+	return payouts, rows.Err()
 }
 
-func (r *loanRepo) DisburseLoan(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error {
+// SaveAgreement stores a generated agreement letter and makes it the letter of the loan, as long as the loan is still
+// invested. A disbursed loan keeps the letter it was disbursed with.
+func (r *loanRepo) SaveAgreement(ctx context.Context, agreement *entity.LoanAgreement, actor entity.Actor) error {
 
 	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
@@ -203,27 +725,49 @@ func (r *loanRepo) DisburseLoan(ctx context.Context, loan *entity.Loan, staffID
 	}
 	defer tx.Rollback()
 
-	query := `UPDATE loan SET status = $1, agreement_letter = $2, disburse_at = $3, updated_at = $5 WHERE loan_id = $4`
-	_, err = tx.ExecContext(ctx, query, loan.Status, loan.AgreementLetter, loan.DisburseAt, loan.ID, "now()")
+	audit, err := beginLoanAudit(ctx, tx, agreement.LoanID)
 	if err != nil {
 		return err
 	}
+	if audit.before.Status != entity.LoanStatusInvested {
+		return apperror.ErrLoanConcurrentUpdate
+	}
 
-	loanPrev := entity.Loan{
-		ID:              loan.ID,
-		Status:          "invested",
-		AgreementLetter: "",
+	query := `UPDATE loan SET agreement_letter = $1, updated_at = now() WHERE loan_id = $2`
+	_, err = tx.ExecContext(ctx, query, agreement.Link, agreement.LoanID)
+	if err != nil {
+		return err
 	}
-	loanAfter := loanPrev
-	loanAfter.Status = loan.Status
-	loanAfter.AgreementLetter = loan.AgreementLetter
 
-	queryLoanStatusHistory := `INSERT INTO loan_status_history (loan_id, before, after, updated_by, updated_at)
-	VALUES ($1, $2, $3, $4, $5)`
-	_, err = tx.Exec(queryLoanStatusHistory, loan.ID, loanPrev, loanAfter, staffID, "now()")
+	query = `INSERT INTO loan_agreement (agreement_id, loan_id, template_version, html, pdf, document_key, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = tx.ExecContext(ctx, query, agreement.ID, agreement.LoanID, agreement.TemplateVersion, agreement.HTML, agreement.PDF,
+		agreement.DocumentKey, agreement.CreatedAt)
 	if err != nil {
 		return err
 	}
 
+	reason := fmt.Sprintf("agreement letter %s generated from template %s", agreement.ID, agreement.TemplateVersion)
+	if err := audit.record(ctx, tx, actor, reason); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
+
+// GetAgreement returns the latest agreement letter generated for the loan
+func (r *loanRepo) GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error) {
+
+	var agreement entity.LoanAgreement
+	query := `SELECT agreement_id, loan_id, template_version, html, pdf, created_at FROM loan_agreement
+	WHERE loan_id = $1 ORDER BY created_at DESC LIMIT 1`
+	err := r.DB.QueryRowContext(ctx, query, loanID).Scan(&agreement.ID, &agreement.LoanID, &agreement.TemplateVersion,
+		&agreement.HTML, &agreement.PDF, &agreement.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.ErrAgreementNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &agreement, nil
+}
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	notificationRepo struct {
+		*postgres.Postgres
+	}
+)
+
+func NewNotificationRepo(pg *postgres.Postgres) *notificationRepo {
+	return &notificationRepo{pg}
+}
+
+// insertLoanInvestedNotifications queues, within tx, one notification to every investor of the loan that has just
+// been fully funded. An investor already notified about the loan is not notified again.
+func insertLoanInvestedNotifications(ctx context.Context, tx *sql.Tx, loanID uuid.UUID) error {
+
+	query := `SELECT DISTINCT investor_id FROM loan_investment WHERE loan_id = $1 AND status = $2`
+	rows, err := tx.QueryContext(ctx, query, loanID, entity.InvestmentStatusActive)
+	if err != nil {
+		return err
+	}
+	var investorIDs []uuid.UUID
+	for rows.Next() {
+		var investorID uuid.UUID
+		if err := rows.Scan(&investorID); err != nil {
+			rows.Close()
+			return err
+		}
+		investorIDs = append(investorIDs, investorID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO notification (notification_id, kind, loan_id, investor_id, status, next_attempt_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, now(), now()) ON CONFLICT (kind, loan_id, investor_id) DO NOTHING`
+	for _, investorID := range investorIDs {
+		_, err := tx.ExecContext(ctx, query, uuid.New(), entity.NotificationKindLoanInvested, loanID, investorID,
+			entity.NotificationStatusPending)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimNotifications leases up to limit pending notifications due for sending, together with the recipient,
+// the loan terms and agreement letter, the investor's pledge and the loan's latest generated letter the message is made of
+func (r *notificationRepo) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error) {
+
+	query := `WITH claimed AS (
+		UPDATE notification SET next_attempt_at = now() + $3 * interval '1 millisecond'
+		WHERE notification_id IN (
+			SELECT notification_id FROM notification WHERE status = $1 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING notification_id, kind, loan_id, investor_id, status, attempts, next_attempt_at, created_at
+	)
+	SELECT c.notification_id, c.kind, c.loan_id, c.investor_id, c.status, c.attempts, c.next_attempt_at, c.created_at,
+	COALESCE(ct.email, ''), l.principal_amount, l.currency, l.interest_rate_bps, l.tenor_months, l.repayment_method,
+	COALESCE(l.agreement_letter, ''),
+	(SELECT COALESCE(SUM(i.amount), 0) FROM loan_investment i WHERE i.loan_id = c.loan_id AND i.investor_id = c.investor_id AND i.status = $4),
+	COALESCE((SELECT a.document_key FROM loan_agreement a WHERE a.loan_id = c.loan_id ORDER BY a.created_at DESC LIMIT 1), '')
+	FROM claimed c JOIN loan l ON l.loan_id = c.loan_id LEFT JOIN investor_contact ct ON ct.investor_id = c.investor_id
+	ORDER BY c.created_at, c.notification_id`
+	rows, err := r.DB.QueryContext(ctx, query, entity.NotificationStatusPending, limit, lease.Milliseconds(), entity.InvestmentStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []entity.Notification{}
+	for rows.Next() {
+		var n entity.Notification
+		err := rows.Scan(&n.ID, &n.Kind, &n.LoanID, &n.InvestorID, &n.Status, &n.Attempts, &n.NextAttemptAt, &n.CreatedAt,
+			&n.Recipient, &n.Loan.PrincipalAmount.Amount, &n.Loan.PrincipalAmount.Currency, &n.Loan.InterestRate,
+			&n.Loan.TenorMonths, &n.Loan.RepaymentMethod, &n.Loan.AgreementLetter, &n.Amount.Amount, &n.AgreementKey)
+		if err != nil {
+			return nil, err
+		}
+		n.Loan.ID = n.LoanID
+		n.Amount.Currency = n.Loan.PrincipalAmount.Currency
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepo) MarkSent(ctx context.Context, notificationID uuid.UUID) error {
+	query := `UPDATE notification SET status = $2, attempts = attempts + 1, last_error = NULL, sent_at = now() WHERE notification_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, notificationID, entity.NotificationStatusSent)
+	return err
+}
+
+// MarkFailed records a failed attempt, the notification is tried again at retryAt unless it is given up for good
+func (r *notificationRepo) MarkFailed(ctx context.Context, notificationID uuid.UUID, reason string, retryAt time.Time, giveUp bool) error {
+	status := entity.NotificationStatusPending
+	if giveUp {
+		status = entity.NotificationStatusFailed
+	}
+
+	query := `UPDATE notification SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4 WHERE notification_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, notificationID, status, reason, retryAt)
+	return err
+}
+
+func (r *notificationRepo) ListNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error) {
+
+	query := `SELECT notification_id, kind, loan_id, investor_id, status, attempts, COALESCE(last_error, ''), next_attempt_at, sent_at, created_at
+	FROM notification WHERE loan_id = $1 ORDER BY created_at, notification_id`
+	rows, err := r.DB.QueryContext(ctx, query, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []entity.Notification{}
+	for rows.Next() {
+		var (
+			n      entity.Notification
+			sentAt sql.NullTime
+		)
+		err := rows.Scan(&n.ID, &n.Kind, &n.LoanID, &n.InvestorID, &n.Status, &n.Attempts, &n.LastError, &n.NextAttemptAt,
+			&sentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepo) SaveContact(ctx context.Context, contact *entity.InvestorContact) error {
+	query := `INSERT INTO investor_contact (investor_id, email, updated_at) VALUES ($1, $2, now())
+	ON CONFLICT (investor_id) DO UPDATE SET email = EXCLUDED.email, updated_at = EXCLUDED.updated_at
+	RETURNING updated_at`
+	return r.DB.QueryRowContext(ctx, query, contact.InvestorID, contact.Email).Scan(&contact.UpdatedAt)
+}
+
+func (r *notificationRepo) GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error) {
+	contact := entity.InvestorContact{InvestorID: investorID}
+	query := `SELECT email, updated_at FROM investor_contact WHERE investor_id = $1`
+	err := r.DB.QueryRowContext(ctx, query, investorID).Scan(&contact.Email, &contact.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.ErrContactNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	outboxRepo struct {
+		*postgres.Postgres
+	}
+)
+
+func NewOutboxRepo(pg *postgres.Postgres) *outboxRepo {
+	return &outboxRepo{pg}
+}
+
+// insertLoanEvent writes the event of a loan status change to the outbox within tx, so the event exists
+// if and only if the change is committed. Its deliveries to the subscribed webhooks are queued along.
+func insertLoanEvent(ctx context.Context, tx *sql.Tx, change entity.LoanStatusChanged) error {
+	event, err := entity.NewLoanStatusEvent(change, time.Now())
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO outbox_event (event_id, event_type, event_version, aggregate_id, payload, occurred_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, event.ID, event.Type, event.Version, event.AggregateID, []byte(event.Payload), event.OccurredAt)
+	if err != nil {
+		return err
+	}
+
+	return insertWebhookDeliveries(ctx, tx, event)
+}
+
+// ClaimEvents leases up to limit unpublished events to the caller for lease. Only the oldest unpublished event of
+// each loan can be claimed, so a loan's events are published in the order they happened even by several relays.
+func (r *outboxRepo) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEvent, error) {
+
+	query := `UPDATE outbox_event SET available_at = now() + $2 * interval '1 millisecond'
+	WHERE event_id IN (
+		SELECT e.event_id FROM outbox_event e
+		WHERE e.published_at IS NULL AND e.available_at <= now()
+		AND NOT EXISTS (SELECT 1 FROM outbox_event p WHERE p.aggregate_id = e.aggregate_id AND p.published_at IS NULL AND p.sequence < e.sequence)
+		ORDER BY e.sequence LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING event_id, event_type, event_version, aggregate_id, payload, occurred_at, attempts, sequence`
+	rows, err := r.DB.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		event    entity.OutboxEvent
+		sequence int64
+	}
+	var all []claimed
+	for rows.Next() {
+		var (
+			c       claimed
+			payload []byte
+		)
+		err := rows.Scan(&c.event.ID, &c.event.Type, &c.event.Version, &c.event.AggregateID, &payload, &c.event.OccurredAt,
+			&c.event.Attempts, &c.sequence)
+		if err != nil {
+			return nil, err
+		}
+		c.event.Payload = payload
+		all = append(all, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//RETURNING does not keep the order of the sub-select
+	sort.Slice(all, func(i, j int) bool { return all[i].sequence < all[j].sequence })
+	events := make([]entity.OutboxEvent, len(all))
+	for i := range all {
+		events[i] = all[i].event
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, eventID uuid.UUID) error {
+	query := `UPDATE outbox_event SET published_at = now(), last_error = NULL WHERE event_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, eventID)
+	return err
+}
+
+// MarkFailed records a failed publication, the event is not claimed again before retryAt
+func (r *outboxRepo) MarkFailed(ctx context.Context, eventID uuid.UUID, reason string, retryAt time.Time) error {
+	query := `UPDATE outbox_event SET attempts = attempts + 1, last_error = $2, available_at = $3 WHERE event_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, eventID, reason, retryAt)
+	return err
+}
@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	walletRepo struct {
+		*postgres.Postgres
+	}
+)
+
+func NewWalletRepo(pg *postgres.Postgres) *walletRepo {
+	return &walletRepo{pg}
+}
+
+func (r *walletRepo) TopUp(ctx context.Context, topUp entity.WalletTopUp) error {
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := creditWallet(ctx, tx, topUp.InvestorID, topUp.Amount); err != nil {
+		return err
+	}
+
+	if err := insertLedgerTransaction(ctx, tx, entity.TopUpTransaction(topUp)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *walletRepo) ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error) {
+
+	query := `SELECT investor_id, currency, available, held, updated_at FROM investor_wallet WHERE investor_id = $1 ORDER BY currency`
+	rows, err := r.DB.QueryContext(ctx, query, investorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []entity.Wallet{}
+	for rows.Next() {
+		var (
+			wallet   entity.Wallet
+			currency string
+		)
+
+		err := rows.Scan(&wallet.InvestorID, &currency, &wallet.Available.Amount, &wallet.Held.Amount, &wallet.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		wallet.Available.Currency = currency
+		wallet.Held.Currency = currency
+		wallet.Holds = []entity.WalletHold{}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	holds, err := r.listHolds(ctx, investorID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hold := range holds {
+		for i := range wallets {
+			if wallets[i].Available.SameCurrency(hold.Amount) {
+				wallets[i].Holds = append(wallets[i].Holds, hold)
+			}
+		}
+	}
+
+	return wallets, nil
+}
+
+// listHolds returns the investor's pledges that still reserve funds
+func (r *walletRepo) listHolds(ctx context.Context, investorID uuid.UUID) ([]entity.WalletHold, error) {
+
+	query := `SELECT hold_id, investor_id, loan_id, loan_investment_id, amount, currency, status, created_at
+	FROM wallet_hold WHERE investor_id = $1 AND status = $2 ORDER BY created_at, hold_id`
+	rows, err := r.DB.QueryContext(ctx, query, investorID, entity.HoldStatusHeld)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHolds(rows)
+}
+
+func scanHolds(rows *sql.Rows) ([]entity.WalletHold, error) {
+	holds := []entity.WalletHold{}
+	for rows.Next() {
+		var hold entity.WalletHold
+
+		err := rows.Scan(&hold.ID, &hold.InvestorID, &hold.LoanID, &hold.InvestmentID, &hold.Amount.Amount, &hold.Amount.Currency,
+			&hold.Status, &hold.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
+
+// creditWallet adds amount to the available balance of the investor's wallet, opening the wallet on its first credit
+func creditWallet(ctx context.Context, tx *sql.Tx, investorID uuid.UUID, amount entity.Money) error {
+	query := `INSERT INTO investor_wallet (investor_id, currency, available, held, updated_at) VALUES ($1, $2, $3, 0, now())
+	ON CONFLICT (investor_id, currency) DO UPDATE SET available = investor_wallet.available + EXCLUDED.available, updated_at = now()`
+	_, err := tx.ExecContext(ctx, query, investorID, amount.Currency, amount.Amount)
+	return err
+}
+
+// lockInvestorTotals locks the investor's wallet in the currency of investment within tx, the lock holdFunds takes,
+// and sums the investor's active pledges on the loan and on all loans counting towards exposure. Pledges of the same
+// investor, to any loan, are serialized by the lock so the totals cannot move before the pledge is made.
+func lockInvestorTotals(ctx context.Context, tx *sql.Tx, investment entity.LoanInvestment) (entity.InvestorTotals, error) {
+
+	var totals entity.InvestorTotals
+	query := `SELECT 1 FROM investor_wallet WHERE investor_id = $1 AND currency = $2 FOR UPDATE`
+	var locked int
+	err := tx.QueryRowContext(ctx, query, investment.InvestorID, investment.Amount.Currency).Scan(&locked)
+	if err != nil && err != sql.ErrNoRows { //without a wallet the pledge is refused by holdFunds anyway
+		return totals, err
+	}
+
+	query = `SELECT COALESCE(SUM(i.amount) FILTER (WHERE i.loan_id = $2), 0), COALESCE(SUM(i.amount), 0)
+	FROM loan_investment i JOIN loan l ON l.loan_id = i.loan_id
+	WHERE i.investor_id = $1 AND i.currency = $3 AND i.status = $4 AND l.status::text = ANY($5)`
+	err = tx.QueryRowContext(ctx, query, investment.InvestorID, investment.LoanID, investment.Amount.Currency,
+		entity.InvestmentStatusActive, pq.Array(entity.ExposureStatuses)).Scan(&totals.OnLoan, &totals.Exposure)
+	return totals, err
+}
+
+// holdFunds reserves the pledged amount on the investor's wallet within tx. The wallet row is locked so two
+// concurrent pledges cannot both spend the same balance.
+func holdFunds(ctx context.Context, tx *sql.Tx, investment entity.LoanInvestment) error {
+
+	var available int64
+	query := `SELECT available FROM investor_wallet WHERE investor_id = $1 AND currency = $2 FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, investment.InvestorID, investment.Amount.Currency).Scan(&available)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if investment.Amount.Amount > available {
+		return apperror.ErrInsufficientBalance.Withf("available balance is %s",
+			entity.NewMoney(available, investment.Amount.Currency).String())
+	}
+
+	query = `UPDATE investor_wallet SET available = available - $3, held = held + $3, updated_at = now()
+	WHERE investor_id = $1 AND currency = $2`
+	_, err = tx.ExecContext(ctx, query, investment.InvestorID, investment.Amount.Currency, investment.Amount.Amount)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO wallet_hold (hold_id, investor_id, loan_id, loan_investment_id, amount, currency, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())`
+	_, err = tx.ExecContext(ctx, query, uuid.New(), investment.InvestorID, investment.LoanID, investment.ID,
+		investment.Amount.Amount, investment.Amount.Currency, entity.HoldStatusHeld)
+	return err
+}
+
+// captureHolds consumes the funds held for a loan once it is disbursed
+func captureHolds(ctx context.Context, tx *sql.Tx, loanID uuid.UUID) error {
+	_, err := settleHolds(ctx, tx, loanID, uuid.Nil, entity.HoldStatusCaptured)
+	return err
+}
+
+// releaseHolds gives the funds held for a loan back to the investors and books the refund out of the loan escrow
+func releaseHolds(ctx context.Context, tx *sql.Tx, loanID uuid.UUID) error {
+	return releaseInvestmentHold(ctx, tx, loanID, uuid.Nil)
+}
+
+// releaseInvestmentHold is releaseHolds narrowed down to a single investment, all of the loan's when investmentID is uuid.Nil
+func releaseInvestmentHold(ctx context.Context, tx *sql.Tx, loanID, investmentID uuid.UUID) error {
+	holds, err := settleHolds(ctx, tx, loanID, investmentID, entity.HoldStatusReleased)
+	if err != nil || len(holds) == 0 {
+		return err
+	}
+
+	for _, hold := range holds {
+		if err := creditWallet(ctx, tx, hold.InvestorID, hold.Amount); err != nil {
+			return err
+		}
+	}
+
+	return insertLedgerTransaction(ctx, tx, entity.ReleaseTransaction(loanID, holds))
+}
+
+// settleHolds moves the funds held for the loan, or for one of its investments, to status and takes them off the
+// wallets' held balance
+func settleHolds(ctx context.Context, tx *sql.Tx, loanID, investmentID uuid.UUID, status string) ([]entity.WalletHold, error) {
+
+	query := `UPDATE wallet_hold SET status = $3, updated_at = now() WHERE loan_id = $1 AND status = $2
+	AND ($4::uuid IS NULL OR loan_investment_id = $4)
+	RETURNING hold_id, investor_id, loan_id, loan_investment_id, amount, currency, status, created_at`
+	rows, err := tx.QueryContext(ctx, query, loanID, entity.HoldStatusHeld, status, nullUUID(investmentID))
+	if err != nil {
+		return nil, err
+	}
+	holds, err := scanHolds(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE investor_wallet SET held = held - $3, updated_at = now() WHERE investor_id = $1 AND currency = $2`
+	for _, hold := range holds {
+		_, err = tx.ExecContext(ctx, query, hold.InvestorID, hold.Amount.Currency, hold.Amount.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return holds, nil
+}
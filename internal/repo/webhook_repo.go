@@ -0,0 +1,280 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/postgres"
+)
+
+type (
+	webhookRepo struct {
+		*postgres.Postgres
+	}
+)
+
+func NewWebhookRepo(pg *postgres.Postgres) *webhookRepo {
+	return &webhookRepo{pg}
+}
+
+const webhookColumns = `webhook_id, url, event_types, active, created_by, created_at, updated_at`
+
+const deliveryColumns = `delivery_id, webhook_id, event_id, event_type, loan_id, payload, status, attempts,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), next_attempt_at, delivered_at, created_at`
+
+// insertWebhookDeliveries queues, within tx, the delivery of event to every active webhook subscribed to it
+func insertWebhookDeliveries(ctx context.Context, tx *sql.Tx, event entity.OutboxEvent) error {
+
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscription WHERE active`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	webhooks, err := scanWebhooks(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO webhook_delivery (delivery_id, webhook_id, event_id, event_type, loan_id, payload, status, next_attempt_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())`
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, query, uuid.New(), webhook.ID, event.ID, event.Type, event.AggregateID, body,
+			entity.WebhookDeliveryStatusPending)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *webhookRepo) CreateWebhook(ctx context.Context, webhook *entity.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscription (webhook_id, url, secret, event_types, active, created_by, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, now(), now()) RETURNING created_at, updated_at`
+	return r.DB.QueryRowContext(ctx, query, webhook.ID, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes),
+		webhook.Active, webhook.CreatedBy).Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+}
+
+func (r *webhookRepo) ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscription ORDER BY created_at, webhook_id`
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhooks(rows)
+}
+
+func (r *webhookRepo) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*entity.WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscription WHERE webhook_id = $1`
+	rows, err := r.DB.QueryContext(ctx, query, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks, err := scanWebhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return nil, apperror.ErrWebhookNotFound
+	}
+	return &webhooks[0], nil
+}
+
+func (r *webhookRepo) UpdateWebhook(ctx context.Context, webhook *entity.WebhookSubscription) error {
+	query := `UPDATE webhook_subscription SET url = $2, event_types = $3, active = $4, updated_at = now()
+	WHERE webhook_id = $1 RETURNING updated_at`
+	err := r.DB.QueryRowContext(ctx, query, webhook.ID, webhook.URL, pq.Array(webhook.EventTypes), webhook.Active).
+		Scan(&webhook.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return apperror.ErrWebhookNotFound
+	}
+	return err
+}
+
+// DeleteWebhook removes the webhook together with its delivery log
+func (r *webhookRepo) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM webhook_subscription WHERE webhook_id = $1`, webhookID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return apperror.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the webhook's latest deliveries, newest first
+func (r *webhookRepo) ListDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_delivery
+	WHERE webhook_id = $1 AND ($2 = '' OR status = $2) ORDER BY sequence DESC LIMIT $3`
+	rows, err := r.DB.QueryContext(ctx, query, filter.WebhookID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// ClaimDeliveries leases up to limit pending deliveries to active webhooks to the caller for lease. Only the oldest
+// pending delivery of each loan to a webhook can be claimed, so a webhook receives a loan's events in order.
+func (r *webhookRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+
+	query := `WITH claimed AS (
+		UPDATE webhook_delivery SET next_attempt_at = now() + $3 * interval '1 millisecond'
+		WHERE delivery_id IN (
+			SELECT d.delivery_id FROM webhook_delivery d JOIN webhook_subscription w ON w.webhook_id = d.webhook_id
+			WHERE d.status = $1 AND d.next_attempt_at <= now() AND w.active
+			AND NOT EXISTS (SELECT 1 FROM webhook_delivery p WHERE p.webhook_id = d.webhook_id AND p.loan_id = d.loan_id
+				AND p.status = $1 AND p.sequence < d.sequence)
+			ORDER BY d.sequence LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING *
+	)
+	SELECT c.delivery_id, c.webhook_id, c.event_id, c.event_type, c.loan_id, c.payload, c.status, c.attempts,
+	COALESCE(c.last_status_code, 0), COALESCE(c.last_error, ''), c.next_attempt_at, c.delivered_at, c.created_at, w.url, w.secret
+	FROM claimed c JOIN webhook_subscription w ON w.webhook_id = c.webhook_id
+	ORDER BY c.sequence`
+	rows, err := r.DB.QueryContext(ctx, query, entity.WebhookDeliveryStatusPending, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []entity.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d           entity.WebhookDelivery
+			payload     []byte
+			deliveredAt sql.NullTime
+		)
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.LoanID, &payload, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &deliveredAt, &d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepo) MarkDelivered(ctx context.Context, deliveryID uuid.UUID, statusCode int) error {
+	query := `UPDATE webhook_delivery SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = NULL,
+	delivered_at = now() WHERE delivery_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, deliveryID, entity.WebhookDeliveryStatusDelivered, statusCode)
+	return err
+}
+
+// MarkFailed records a failed attempt, statusCode is 0 when no response was received. The delivery is tried again at
+// retryAt unless it is dead-lettered.
+func (r *webhookRepo) MarkFailed(ctx context.Context, deliveryID uuid.UUID, statusCode int, reason string, retryAt time.Time, dead bool) error {
+	status := entity.WebhookDeliveryStatusPending
+	if dead {
+		status = entity.WebhookDeliveryStatusDead
+	}
+
+	query := `UPDATE webhook_delivery SET status = $2, attempts = attempts + 1, last_status_code = NULLIF($3, 0), last_error = $4,
+	next_attempt_at = $5 WHERE delivery_id = $1`
+	_, err := r.DB.ExecContext(ctx, query, deliveryID, status, statusCode, reason, retryAt)
+	return err
+}
+
+// RequeueDelivery puts a dead delivery back in the queue with a fresh set of attempts
+func (r *webhookRepo) RequeueDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*entity.WebhookDelivery, error) {
+
+	query := `UPDATE webhook_delivery SET status = $3, attempts = 0, next_attempt_at = now()
+	WHERE webhook_id = $1 AND delivery_id = $2 AND status = $4
+	RETURNING ` + deliveryColumns
+	rows, err := r.DB.QueryContext(ctx, query, webhookID, deliveryID, entity.WebhookDeliveryStatusPending,
+		entity.WebhookDeliveryStatusDead)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := scanDeliveries(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 1 {
+		return &deliveries[0], nil
+	}
+
+	var status string
+	query = `SELECT status FROM webhook_delivery WHERE webhook_id = $1 AND delivery_id = $2`
+	err = r.DB.QueryRowContext(ctx, query, webhookID, deliveryID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, apperror.ErrDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, apperror.ErrDeliveryNotDead.Withf("delivery is %s, only dead deliveries can be sent again", status)
+}
+
+func scanWebhooks(rows *sql.Rows) ([]entity.WebhookSubscription, error) {
+	webhooks := []entity.WebhookSubscription{}
+	for rows.Next() {
+		var webhook entity.WebhookSubscription
+		err := rows.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.EventTypes), &webhook.Active, &webhook.CreatedBy,
+			&webhook.CreatedAt, &webhook.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if webhook.EventTypes == nil {
+			webhook.EventTypes = []string{}
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func scanDeliveries(rows *sql.Rows) ([]entity.WebhookDelivery, error) {
+	deliveries := []entity.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d           entity.WebhookDelivery
+			payload     []byte
+			deliveredAt sql.NullTime
+		)
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.LoanID, &payload, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &deliveredAt, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/pdf"
+)
+
+// agreementTemplateVersion is the template new letters are generated from. Templates are never edited once released,
+// a wording change is a new version so every stored letter can be traced back to the exact text it was made from.
+const agreementTemplateVersion = "v1"
+
+//go:embed templates/agreement_*.html templates/agreement_*.txt
+var agreementTemplates embed.FS
+
+// agreementData is what the agreement templates are filled with
+type agreementData struct {
+	Version     string
+	Loan        *entity.Loan
+	GeneratedAt time.Time
+}
+
+// agreementLink is where the API serves the loan's latest agreement letter
+func agreementLink(loanID uuid.UUID) string {
+	return fmt.Sprintf("/v1/loans/%s/agreement", loanID)
+}
+
+// IssueAgreement generates the agreement letter of a fully invested loan and makes it the loan's agreement letter,
+// actor is recorded in the loan history as the one who issued it
+func (s *loanService) IssueAgreement(ctx context.Context, loanID uuid.UUID, actor entity.Actor) (*entity.LoanAgreement, error) {
+
+	//GetLoanByID fills in the returns of every investor, which the letter lists
+	loan, err := s.GetLoanByID(ctx, loanID)
+	if err != nil {
+		log.Printf("[IssueAgreement] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	if loan.Status != entity.LoanStatusInvested {
+		return nil, apperror.ErrAgreementNotAvailable.Withf("agreement letter is issued once the loan is fully invested, it is %s", loan.Status)
+	}
+
+	agreement, err := renderAgreement(loan, agreementTemplateVersion, time.Now())
+	if err != nil {
+		log.Printf("[IssueAgreement] error rendering agreement: %s", err.Error())
+		return nil, err
+	}
+
+	//the copy kept in the document store can be linked to with a signed URL, e.g. from e-mails, without a bearer token
+	if s.documents == nil {
+		return nil, errNoDocumentStore
+	}
+	agreement.DocumentKey = documentKey(documentKindAgreement, "agreement.pdf", agreement.PDF)
+	if err := s.documents.Put(ctx, agreement.DocumentKey, "application/pdf", agreement.PDF); err != nil {
+		log.Printf("[IssueAgreement] error storing agreement: %s", err.Error())
+		return nil, err
+	}
+
+	if err := s.repo.SaveAgreement(ctx, agreement, actor); err != nil {
+		log.Printf("[IssueAgreement] error saving agreement: %s", err.Error())
+		return nil, err
+	}
+
+	return agreement, nil
+}
+
+func (s *loanService) GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error) {
+	agreement, err := s.repo.GetAgreement(ctx, loanID)
+	if err != nil {
+		log.Printf("[GetAgreement] error getting agreement: %s", err.Error())
+		return nil, err
+	}
+
+	agreement.Link = agreementLink(agreement.LoanID)
+	return agreement, nil
+}
+
+// renderAgreement fills the version's templates with the loan, the HTML template gives the HTML letter
+// and the text one the PDF letter
+func renderAgreement(loan *entity.Loan, version string, generatedAt time.Time) (*entity.LoanAgreement, error) {
+	data := agreementData{Version: version, Loan: loan, GeneratedAt: generatedAt}
+
+	htmlTmpl, err := htmltemplate.ParseFS(agreementTemplates, "templates/agreement_"+version+".html")
+	if err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	textTmpl, err := texttemplate.ParseFS(agreementTemplates, "templates/agreement_"+version+".txt")
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	doc.Text(text.String())
+
+	return &entity.LoanAgreement{
+		ID:              uuid.New(),
+		LoanID:          loan.ID,
+		TemplateVersion: version,
+		HTML:            html.Bytes(),
+		PDF:             doc.Bytes(),
+		Link:            agreementLink(loan.ID),
+		CreatedAt:       generatedAt,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func Test_RenderAgreement(t *testing.T) {
+	t.Parallel()
+
+	investorID := uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886")
+	loan := &entity.Loan{
+		ID:              uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b"),
+		BorrowerID:      uuid.MustParse("d149aaa5-e7e8-4820-93a0-e278dcde447a"),
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1000,
+		TenorMonths:     12,
+		RepaymentMethod: entity.RepaymentMethodFlat,
+		Status:          entity.LoanStatusInvested,
+		InvestorReturns: []entity.InvestorReturn{{
+			InvestorID:     investorID,
+			Amount:         entity.NewMoney(1000000, "IDR"),
+			PrincipalShare: entity.NewMoney(1000000, "IDR"),
+			InterestShare:  entity.NewMoney(100000, "IDR"),
+			TotalReturn:    entity.NewMoney(1100000, "IDR"),
+		}},
+	}
+	generatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	agreement, err := renderAgreement(loan, agreementTemplateVersion, generatedAt)
+	assert.Nil(t, err)
+	assert.Equal(t, loan.ID, agreement.LoanID)
+	assert.Equal(t, "v1", agreement.TemplateVersion)
+	assert.Equal(t, "/v1/loans/36b84065-1de5-47df-b1a6-311ff28dfe5b/agreement", agreement.Link)
+	assert.Equal(t, generatedAt, agreement.CreatedAt)
+
+	for _, content := range [][]byte{agreement.HTML, agreement.PDF} {
+		assert.True(t, bytes.Contains(content, []byte(loan.ID.String())))
+		assert.True(t, bytes.Contains(content, []byte(investorID.String())))
+	}
+	assert.True(t, bytes.HasPrefix(agreement.PDF, []byte("%PDF-")))
+
+	_, err = renderAgreement(loan, "v0", generatedAt)
+	assert.NotNil(t, err)
+}
+
+func Test_IssueAgreement(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, store := setupDocumentStore(t)
+	ctx := context.Background()
+	loanID := uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b")
+	staff := entity.StaffActor(uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82"))
+
+	t.Run("issue agreement failed, loan is not invested", func(t *testing.T) {
+		loan := entity.Loan{ID: loanID, Status: entity.LoanStatusApproved, PrincipalAmount: entity.NewMoney(1000000, "IDR")}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+
+		_, err := svc.IssueAgreement(ctx, loanID, staff)
+		assert.True(t, errors.Is(err, apperror.ErrAgreementNotAvailable))
+	})
+
+	t.Run("issue agreement failed, no document store to keep the letter in", func(t *testing.T) {
+		svc, repo := setupLoanService(t)
+		loan := entity.Loan{ID: loanID, Status: entity.LoanStatusInvested, PrincipalAmount: entity.NewMoney(1000000, "IDR")}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+
+		_, err := svc.IssueAgreement(ctx, loanID, staff)
+		assert.Equal(t, errNoDocumentStore, err)
+	})
+
+	t.Run("issue agreement failed, error storing the PDF", func(t *testing.T) {
+		loan := entity.Loan{ID: loanID, Status: entity.LoanStatusInvested, PrincipalAmount: entity.NewMoney(1000000, "IDR")}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+		store.EXPECT().Put(ctx, gomock.Any(), "application/pdf", gomock.Any()).Return(errors.New("bucket unavailable"))
+
+		_, err := svc.IssueAgreement(ctx, loanID, staff)
+		assert.Equal(t, "bucket unavailable", err.Error())
+	})
+
+	t.Run("issue agreement failed, error saving agreement", func(t *testing.T) {
+		loan := entity.Loan{ID: loanID, Status: entity.LoanStatusInvested, PrincipalAmount: entity.NewMoney(1000000, "IDR")}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+		store.EXPECT().Put(ctx, gomock.Any(), "application/pdf", gomock.Any()).Return(nil)
+		repo.EXPECT().SaveAgreement(ctx, gomock.Any(), staff).Return(errors.New("db error"))
+
+		_, err := svc.IssueAgreement(ctx, loanID, staff)
+		assert.Equal(t, "db error", err.Error())
+	})
+
+	t.Run("issue agreement success, the PDF is kept in the document store", func(t *testing.T) {
+		loan := entity.Loan{ID: loanID, Status: entity.LoanStatusInvested, PrincipalAmount: entity.NewMoney(1000000, "IDR")}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+		var stored string
+		store.EXPECT().Put(ctx, gomock.Any(), "application/pdf", gomock.Any()).DoAndReturn(func(_ context.Context, key, _ string, content []byte) error {
+			assert.True(t, strings.HasPrefix(key, "agreements/"))
+			assert.True(t, strings.HasSuffix(key, ".pdf"))
+			assert.True(t, bytes.HasPrefix(content, []byte("%PDF-")))
+			stored = key
+			return nil
+		})
+		repo.EXPECT().SaveAgreement(ctx, gomock.Any(), staff).DoAndReturn(func(_ context.Context, agreement *entity.LoanAgreement, _ entity.Actor) error {
+			assert.Equal(t, loanID, agreement.LoanID)
+			assert.NotEmpty(t, agreement.HTML)
+			assert.NotEmpty(t, agreement.PDF)
+			assert.Equal(t, stored, agreement.DocumentKey)
+			return nil
+		})
+
+		agreement, err := svc.IssueAgreement(ctx, loanID, staff)
+		assert.Nil(t, err)
+		assert.Equal(t, agreementLink(loanID), agreement.Link)
+	})
+}
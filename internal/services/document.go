@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+const (
+	documentKindAgreement = "agreements" //generated agreement letters and the signed ones uploaded on disbursement
+	documentKindApproval  = "approvals"  //picture proofs of the field validator's visit
+
+	defaultDocumentURLTTL = 15 * time.Minute
+)
+
+type (
+	// DocumentStore keeps uploaded documents under a key and hands out time limited URLs to download them
+	DocumentStore interface {
+		Put(ctx context.Context, key, contentType string, content []byte) error
+		SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	}
+)
+
+var errNoDocumentStore = errors.New("no document store configured")
+
+// WithDocumentStore sets where uploaded documents are kept and how long their download URLs stay valid
+func WithDocumentStore(store DocumentStore, urlTTL time.Duration) Option {
+	return func(s *loanService) {
+		s.documents = store
+		if urlTTL > 0 {
+			s.documentURLTTL = urlTTL
+		}
+	}
+}
+
+// documentKey names a document after its content: the same file uploaded twice is stored once, and a stored document
+// can never be overwritten by another one
+func documentKey(kind, filename string, content []byte) string {
+	sum := sha256.Sum256(content)
+	return kind + "/" + hex.EncodeToString(sum[:]) + strings.ToLower(path.Ext(filename))
+}
+
+// isDocumentKey tells keys of the document store apart from the references stored before it existed,
+// i.e. local upload paths and the API link of the generated agreement letter
+func isDocumentKey(ref string) bool {
+	return strings.HasPrefix(ref, documentKindAgreement+"/") || strings.HasPrefix(ref, documentKindApproval+"/")
+}
+
+// storeDocument puts an uploaded file in the document store and returns its key
+func (s *loanService) storeDocument(ctx context.Context, kind string, file *multipart.FileHeader) (string, error) {
+	if s.documents == nil {
+		return "", errNoDocumentStore
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := file.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(content)
+	}
+
+	key := documentKey(kind, file.Filename, content)
+	if err := s.documents.Put(ctx, key, contentType, content); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// documentURL turns a stored document reference into a download URL, references that are not document keys are
+// returned as they are
+func (s *loanService) documentURL(ctx context.Context, ref string) string {
+	if s.documents == nil || !isDocumentKey(ref) {
+		return ref
+	}
+
+	signed, err := s.documents.SignedURL(ctx, ref, s.documentURLTTL)
+	if err != nil {
+		log.Printf("[documentURL] error signing document URL: %s", err.Error())
+		return ""
+	}
+	return signed
+}
+
+// signLoanDocuments replaces the document keys of a loan by download URLs
+func (s *loanService) signLoanDocuments(ctx context.Context, loan *entity.Loan) {
+	loan.AgreementLetter = s.documentURL(ctx, loan.AgreementLetter)
+	if loan.Approval != nil {
+		loan.Approval.PictureProof = s.documentURL(ctx, loan.Approval.PictureProof)
+	}
+}
+
+// signInvestmentDocuments replaces the agreement letter keys of investments by download URLs
+func (s *loanService) signInvestmentDocuments(ctx context.Context, investments []entity.InvestmentDetail) {
+	for i := range investments {
+		investments[i].AgreementLetter = s.documentURL(ctx, investments[i].AgreementLetter)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/services/mock"
+)
+
+func setupDocumentStore(t *testing.T) (*loanService, *mock.MockLoanRepo, *mock.MockDocumentStore) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	repo := mock.NewMockLoanRepo(ctrl)
+	store := mock.NewMockDocumentStore(ctrl)
+
+	return NewLoanService(repo, WithDocumentStore(store, 5*time.Minute)), repo, store
+}
+
+// uploadedFile builds the file header gin binds from a multipart upload
+func uploadedFile(t *testing.T, field, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	assert.Nil(t, err)
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	assert.Nil(t, err)
+	return form.File[field][0]
+}
+
+func Test_DocumentKey(t *testing.T) {
+	t.Parallel()
+
+	key := documentKey(documentKindAgreement, "Signed Agreement.PDF", []byte("%PDF-1.4"))
+	assert.Equal(t, "agreements/e16fa5d9b51928755db85b917f0297babaf22c7a47e97d9212adab56e61ba04e.pdf", key)
+	assert.True(t, isDocumentKey(key))
+	assert.Equal(t, key, documentKey(documentKindAgreement, "copy.pdf", []byte("%PDF-1.4")))
+
+	assert.False(t, isDocumentKey("./uploads/agreements/loan_agreement.pdf"))
+	assert.False(t, isDocumentKey("/v1/loans/36b84065-1de5-47df-b1a6-311ff28dfe5b/agreement"))
+}
+
+func Test_DisburseLoanStoresAgreement(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, store := setupDocumentStore(t)
+	ctx := context.Background()
+
+	loan := entity.Loan{
+		ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1000,
+		TenorMonths:     12,
+		RepaymentMethod: "flat",
+		Status:          "invested",
+	}
+	loanDisburseReq := entity.LoanDisburseRequest{
+		LoanID:            loan.ID.String(),
+		StaffID:           "75ed6802-8f18-4c5e-95b6-e8bd35e8d940",
+		LoanAgreementDocs: uploadedFile(t, "agreement_file", "signed.pdf", []byte("%PDF-1.4")),
+	}
+	key := documentKey(documentKindAgreement, "signed.pdf", []byte("%PDF-1.4"))
+
+	t.Run("disburse loan failed, error storing the agreement", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		store.EXPECT().Put(ctx, key, "application/pdf", []byte("%PDF-1.4")).Return(errors.New("bucket unavailable"))
+
+		err := svc.DisburseLoan(ctx, loanDisburseReq)
+		assert.Equal(t, "bucket unavailable", err.Error())
+	})
+
+	t.Run("disburse loan success, the agreement letter is the document key", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		store.EXPECT().Put(ctx, key, "application/pdf", []byte("%PDF-1.4")).Return(nil)
+		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), gomock.Len(12), uuid.MustParse(loanDisburseReq.StaffID)).
+			DoAndReturn(func(_ context.Context, disbursed *entity.Loan, _ []entity.LoanInstalment, _ uuid.UUID) error {
+				assert.Equal(t, key, disbursed.AgreementLetter)
+				return nil
+			})
+
+		err := svc.DisburseLoan(ctx, loanDisburseReq)
+		assert.Nil(t, err)
+	})
+}
+
+func Test_SignLoanDocuments(t *testing.T) {
+	t.Parallel()
+
+	svc, repo, store := setupDocumentStore(t)
+	ctx := context.Background()
+	loanID := uuid.MustParse("a98ba4bd-1e09-4134-b244-d89f9a86a44c")
+
+	t.Run("get loan success, document keys are replaced by signed URLs", func(t *testing.T) {
+		loan := entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			AgreementLetter: "agreements/0a1b.pdf",
+			Approval:        &entity.LoanApproval{PictureProof: "approvals/2c3d.jpg"},
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+		store.EXPECT().SignedURL(ctx, "agreements/0a1b.pdf", 5*time.Minute).Return("https://documents/agreements/0a1b.pdf?sig", nil)
+		store.EXPECT().SignedURL(ctx, "approvals/2c3d.jpg", 5*time.Minute).Return("", errors.New("signing failed"))
+
+		res, err := svc.GetLoanByID(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://documents/agreements/0a1b.pdf?sig", res.AgreementLetter)
+		assert.Equal(t, "", res.Approval.PictureProof)
+	})
+
+	t.Run("get loan success, references from before the document store are kept", func(t *testing.T) {
+		loan := entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			AgreementLetter: "/v1/loans/a98ba4bd-1e09-4134-b244-d89f9a86a44c/agreement",
+			Approval:        &entity.LoanApproval{PictureProof: "./uploads/approvals/a98ba4bd_proof.jpg"},
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return([]entity.InvestmentDetail{}, nil)
+
+		res, err := svc.GetLoanByID(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Equal(t, "/v1/loans/a98ba4bd-1e09-4134-b244-d89f9a86a44c/agreement", res.AgreementLetter)
+		assert.Equal(t, "./uploads/approvals/a98ba4bd_proof.jpg", res.Approval.PictureProof)
+	})
+}
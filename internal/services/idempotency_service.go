@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
+//go:generate mockgen -source=idempotency_service.go -package=mock -destination=mock/idempotency_service_mock.go
+type (
+	IdempotencyService interface {
+		Begin(ctx context.Context, record entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+		Complete(ctx context.Context, record entity.IdempotencyRecord) error
+		Release(ctx context.Context, record entity.IdempotencyRecord) error
+	}
+
+	idempotencyService struct {
+		repo IdempotencyRepo
+		ttl  time.Duration
+	}
+
+	IdempotencyRepo interface {
+		// ClaimKey stores record unless the caller already has a record for the key created after expiredBefore,
+		// it reports whether the record was stored
+		ClaimKey(ctx context.Context, record entity.IdempotencyRecord, expiredBefore time.Time) (bool, error)
+		GetRecord(ctx context.Context, subject, key string) (*entity.IdempotencyRecord, error)
+		CompleteRecord(ctx context.Context, record entity.IdempotencyRecord) error
+		DeleteRecord(ctx context.Context, subject, key string) error
+	}
+)
+
+// NewIdempotencyService keeps keys for ttl, after which the key can be used for a new request
+func NewIdempotencyService(repo IdempotencyRepo, ttl time.Duration) *idempotencyService {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+	return &idempotencyService{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Begin claims the key for record. It returns nil when the request should be handled, or the completed record whose
+// response must be replayed. A key reused for another request or for a request still in flight is refused.
+func (s *idempotencyService) Begin(ctx context.Context, record entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+
+	record.Status = entity.IdempotencyStatusInProgress
+	claimed, err := s.repo.ClaimKey(ctx, record, time.Now().Add(-s.ttl))
+	if err != nil {
+		log.Printf("[Begin] error claiming idempotency key: %s", err.Error())
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	original, err := s.repo.GetRecord(ctx, record.Subject, record.Key)
+	if err != nil {
+		log.Printf("[Begin] error getting idempotency record: %s", err.Error())
+		return nil, err
+	}
+
+	if original.RequestHash != record.RequestHash {
+		return nil, apperror.ErrIdempotencyKeyReused
+	}
+	if original.Status != entity.IdempotencyStatusCompleted {
+		return nil, apperror.ErrIdempotencyInProgress
+	}
+
+	return original, nil
+}
+
+// Complete stores the response so retries with the same key get it back
+func (s *idempotencyService) Complete(ctx context.Context, record entity.IdempotencyRecord) error {
+	record.Status = entity.IdempotencyStatusCompleted
+	err := s.repo.CompleteRecord(ctx, record)
+	if err != nil {
+		log.Printf("[Complete] error storing idempotent response: %s", err.Error())
+	}
+	return err
+}
+
+// Release forgets the key, used when the request failed on the server side so that a retry is handled again
+func (s *idempotencyService) Release(ctx context.Context, record entity.IdempotencyRecord) error {
+	err := s.repo.DeleteRecord(ctx, record.Subject, record.Key)
+	if err != nil {
+		log.Printf("[Release] error releasing idempotency key: %s", err.Error())
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func setupIdempotencyService(t *testing.T) (*idempotencyService, *mock.MockIdempotencyRepo) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockIdempotencyRepo(ctrl)
+
+	return NewIdempotencyService(repo, time.Hour), repo
+}
+
+func Test_IdempotencyBegin(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupIdempotencyService(t)
+	ctx := context.Background()
+	record := entity.IdempotencyRecord{Subject: "investor-1", Key: "key-1", RequestHash: "hash-1"}
+
+	t.Run("first request is handled", func(t *testing.T) {
+		repo.EXPECT().ClaimKey(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, claimed entity.IdempotencyRecord, expiredBefore time.Time) (bool, error) {
+				assert.Equal(t, entity.IdempotencyStatusInProgress, claimed.Status)
+				assert.WithinDuration(t, time.Now().Add(-time.Hour), expiredBefore, time.Minute)
+				return true, nil
+			})
+
+		original, err := svc.Begin(ctx, record)
+		assert.Nil(t, err)
+		assert.Nil(t, original)
+	})
+
+	t.Run("retry gets the stored response", func(t *testing.T) {
+		stored := record
+		stored.Status = entity.IdempotencyStatusCompleted
+		stored.ResponseStatus = 200
+		stored.ResponseBody = []byte(`{"success":true}`)
+
+		repo.EXPECT().ClaimKey(ctx, gomock.Any(), gomock.Any()).Return(false, nil)
+		repo.EXPECT().GetRecord(ctx, record.Subject, record.Key).Return(&stored, nil)
+
+		original, err := svc.Begin(ctx, record)
+		assert.Nil(t, err)
+		assert.Equal(t, &stored, original)
+	})
+
+	t.Run("key reused with a different request", func(t *testing.T) {
+		stored := record
+		stored.RequestHash = "hash-2"
+		stored.Status = entity.IdempotencyStatusCompleted
+
+		repo.EXPECT().ClaimKey(ctx, gomock.Any(), gomock.Any()).Return(false, nil)
+		repo.EXPECT().GetRecord(ctx, record.Subject, record.Key).Return(&stored, nil)
+
+		_, err := svc.Begin(ctx, record)
+		assert.True(t, errors.Is(err, apperror.ErrIdempotencyKeyReused))
+	})
+
+	t.Run("first request still in flight", func(t *testing.T) {
+		stored := record
+		stored.Status = entity.IdempotencyStatusInProgress
+
+		repo.EXPECT().ClaimKey(ctx, gomock.Any(), gomock.Any()).Return(false, nil)
+		repo.EXPECT().GetRecord(ctx, record.Subject, record.Key).Return(&stored, nil)
+
+		_, err := svc.Begin(ctx, record)
+		assert.True(t, errors.Is(err, apperror.ErrIdempotencyInProgress))
+	})
+
+	t.Run("claim failed", func(t *testing.T) {
+		repo.EXPECT().ClaimKey(ctx, gomock.Any(), gomock.Any()).Return(false, errors.New("db down"))
+
+		_, err := svc.Begin(ctx, record)
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_IdempotencyComplete(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupIdempotencyService(t)
+	ctx := context.Background()
+	record := entity.IdempotencyRecord{Subject: "investor-1", Key: "key-1", RequestHash: "hash-1", ResponseStatus: 200}
+
+	completed := record
+	completed.Status = entity.IdempotencyStatusCompleted
+	repo.EXPECT().CompleteRecord(ctx, completed).Return(nil)
+	assert.Nil(t, svc.Complete(ctx, record))
+
+	repo.EXPECT().DeleteRecord(ctx, record.Subject, record.Key).Return(nil)
+	assert.Nil(t, svc.Release(ctx, record))
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+// InvestmentLimits are the rules a pledge must follow, a zero value disables its rule.
+// Amounts are in minor units of the loan currency.
+type InvestmentLimits struct {
+	MinTicket    int64       //smallest pledge accepted
+	Step         int64       //pledges must be a multiple of it
+	MaxLoanShare entity.Rate //largest share of one loan's principal a single investor may fund
+	MaxExposure  int64       //largest total an investor may have pledged on active loans
+}
+
+// WithInvestmentLimits sets the rules checked on every pledge
+func WithInvestmentLimits(limits InvestmentLimits) Option {
+	return func(s *loanService) {
+		s.limits = limits
+	}
+}
+
+func (l InvestmentLimits) perInvestor() bool {
+	return l.MaxLoanShare > 0 || l.MaxExposure > 0
+}
+
+// checkInvestmentLimits returns the error of the first pledge rule investment breaks. A pledge covering exactly what
+// is left of the loan is exempt from the ticket and step rules, so that a loan can always be completed.
+func (s *loanService) checkInvestmentLimits(loan *entity.Loan, investment entity.LoanInvestment) error {
+
+	amount := investment.Amount
+	closesLoan := amount.Amount == loan.RemainingAmount.Amount
+
+	if s.limits.MinTicket > 0 && amount.Amount < s.limits.MinTicket && !closesLoan {
+		return apperror.ErrBelowMinTicket.Withf("pledge must be at least %s",
+			entity.NewMoney(s.limits.MinTicket, amount.Currency).String())
+	}
+
+	if s.limits.Step > 0 && amount.Amount%s.limits.Step != 0 && !closesLoan {
+		return apperror.ErrNotInStep.Withf("pledge must be a multiple of %s",
+			entity.NewMoney(s.limits.Step, amount.Currency).String())
+	}
+
+	return nil
+}
+
+// investorLimitsCheck returns the check of the per investor rules the repo runs on the investor's totals, under the
+// locks serializing the investor's pledges. It is nil when no such rule is configured.
+func (s *loanService) investorLimitsCheck(loan *entity.Loan, investment entity.LoanInvestment) func(entity.InvestorTotals) error {
+	if !s.limits.perInvestor() {
+		return nil
+	}
+	return func(totals entity.InvestorTotals) error {
+		return s.checkInvestorLimits(loan.PrincipalAmount, investment.Amount, totals)
+	}
+}
+
+// checkInvestorLimits returns the error of the first per investor rule a pledge of amount breaks on top of totals
+func (s *loanService) checkInvestorLimits(principal, amount entity.Money, totals entity.InvestorTotals) error {
+
+	//share compared in basis points of the principal: (pledged + amount) / principal > max share
+	if s.limits.MaxLoanShare > 0 &&
+		(totals.OnLoan+amount.Amount)*10000 > s.limits.MaxLoanShare.Bps()*principal.Amount {
+		return apperror.ErrAboveMaxLoanShare.Withf("an investor may fund at most %s%% of a loan", s.limits.MaxLoanShare.String())
+	}
+
+	if s.limits.MaxExposure > 0 && totals.Exposure+amount.Amount > s.limits.MaxExposure {
+		return apperror.ErrAboveMaxExposure.Withf("an investor may have at most %s pledged on active loans",
+			entity.NewMoney(s.limits.MaxExposure, amount.Currency).String())
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func Test_CheckInvestmentLimits(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockLoanRepo(ctrl)
+	svc := NewLoanService(repo, WithInvestmentLimits(InvestmentLimits{
+		MinTicket:    100000,
+		Step:         50000,
+		MaxLoanShare: 2500, //25%
+		MaxExposure:  3000000,
+	}))
+
+	investorID := uuid.New()
+	loan := &entity.Loan{
+		ID:              uuid.New(),
+		Status:          entity.LoanStatusApproved,
+		PrincipalAmount: entity.NewMoney(2000000, "IDR"),
+		RemainingAmount: entity.NewMoney(1230000, "IDR"),
+	}
+	pledge := func(amount int64) entity.LoanInvestment {
+		return entity.LoanInvestment{LoanID: loan.ID, InvestorID: investorID, Amount: entity.NewMoney(amount, "IDR")}
+	}
+
+	t.Run("below the minimum ticket", func(t *testing.T) {
+		err := svc.checkInvestmentLimits(loan, pledge(50000))
+		assert.True(t, errors.Is(err, apperror.ErrBelowMinTicket))
+	})
+
+	t.Run("not a multiple of the step", func(t *testing.T) {
+		err := svc.checkInvestmentLimits(loan, pledge(120000))
+		assert.True(t, errors.Is(err, apperror.ErrNotInStep))
+	})
+
+	t.Run("above the maximum share of the loan", func(t *testing.T) {
+		check := svc.investorLimitsCheck(loan, pledge(250000))
+
+		//300000 + 250000 is 27.5% of 2000000
+		err := check(entity.InvestorTotals{OnLoan: 300000, Exposure: 300000})
+		assert.True(t, errors.Is(err, apperror.ErrAboveMaxLoanShare))
+	})
+
+	t.Run("above the maximum exposure", func(t *testing.T) {
+		err := svc.investorLimitsCheck(loan, pledge(500000))(entity.InvestorTotals{Exposure: 2500000})
+		assert.Nil(t, err)
+
+		err = svc.investorLimitsCheck(loan, pledge(450000))(entity.InvestorTotals{Exposure: 2600000})
+		assert.True(t, errors.Is(err, apperror.ErrAboveMaxExposure))
+	})
+
+	t.Run("pledge closing the loan is exempt from ticket and step", func(t *testing.T) {
+		closing := &entity.Loan{
+			ID:              loan.ID,
+			PrincipalAmount: loan.PrincipalAmount,
+			RemainingAmount: entity.NewMoney(30000, "IDR"),
+		}
+
+		err := svc.checkInvestmentLimits(closing, pledge(30000))
+		assert.Nil(t, err)
+	})
+
+	t.Run("no limits configured", func(t *testing.T) {
+		unlimited := NewLoanService(repo)
+		assert.Nil(t, unlimited.checkInvestmentLimits(loan, pledge(1)))
+		assert.Nil(t, unlimited.investorLimitsCheck(loan, pledge(1)))
+	})
+}
+
+func Test_InvestLoan_InvestorLimitsCheckedByRepo(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockLoanRepo(ctrl)
+	svc := NewLoanService(repo, WithInvestmentLimits(InvestmentLimits{MaxExposure: 1000000}))
+	ctx := context.Background()
+
+	loan := entity.Loan{
+		ID:              uuid.New(),
+		Status:          entity.LoanStatusApproved,
+		PrincipalAmount: entity.NewMoney(2000000, "IDR"),
+		RemainingAmount: entity.NewMoney(2000000, "IDR"),
+	}
+
+	//the repo runs the check with the totals it read under its locks
+	repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+	repo.EXPECT().AddLoanInvestments(ctx, gomock.Any(), gomock.Not(gomock.Nil())).
+		DoAndReturn(func(_ context.Context, _ entity.LoanInvestment, checkInvestor func(entity.InvestorTotals) error) (bool, error) {
+			return false, checkInvestor(entity.InvestorTotals{Exposure: 900000})
+		})
+
+	err := svc.InvestLoan(ctx, entity.LoanInvestRequest{
+		LoanID:     loan.ID.String(),
+		Amount:     entity.NewMoney(200000, "IDR"),
+		InvestorID: uuid.New(),
+	})
+	assert.True(t, errors.Is(err, apperror.ErrAboveMaxExposure))
+}
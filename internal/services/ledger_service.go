@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+//go:generate mockgen -source=ledger_service.go -package=mock -destination=mock/ledger_service_mock.go
+type (
+	LedgerService interface {
+		ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error)
+		CheckIntegrity(ctx context.Context) (*entity.LedgerIntegrity, error)
+	}
+
+	ledgerService struct {
+		repo LedgerRepo
+	}
+
+	LedgerRepo interface {
+		ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error)
+		LedgerTotals(ctx context.Context) ([]entity.LedgerTotals, error)
+		ListUnbalancedTransactions(ctx context.Context) ([]uuid.UUID, error)
+	}
+)
+
+var ErrInvalidAccountFilter = apperror.Validation("invalid_filter", "account filter is invalid")
+
+func NewLedgerService(repo LedgerRepo) *ledgerService {
+	return &ledgerService{
+		repo: repo,
+	}
+}
+
+func (s *ledgerService) ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error) {
+
+	if filter.Type != "" && (entity.LedgerAccount{Type: filter.Type}).NormalSide() == "" {
+		return nil, ErrInvalidAccountFilter.Withf("unknown account type %q", filter.Type)
+	}
+
+	balances, err := s.repo.ListBalances(ctx, filter)
+	if err != nil {
+		log.Printf("[ListBalances] error listing balances: %s", err.Error())
+		return nil, err
+	}
+
+	//balances are reported on the side that increases the account, e.g. credits minus debits for a wallet
+	for i := range balances {
+		b := &balances[i]
+		b.Balance = entity.NewMoney(b.Debits.Amount-b.Credits.Amount, b.Debits.Currency)
+		if b.NormalSide() == entity.SideCredit {
+			b.Balance.Amount = -b.Balance.Amount
+		}
+	}
+
+	return balances, nil
+}
+
+// CheckIntegrity verifies the ledger invariant: debits equal credits in every currency and in every transaction
+func (s *ledgerService) CheckIntegrity(ctx context.Context) (*entity.LedgerIntegrity, error) {
+
+	totals, err := s.repo.LedgerTotals(ctx)
+	if err != nil {
+		log.Printf("[CheckIntegrity] error getting ledger totals: %s", err.Error())
+		return nil, err
+	}
+
+	unbalanced, err := s.repo.ListUnbalancedTransactions(ctx)
+	if err != nil {
+		log.Printf("[CheckIntegrity] error listing unbalanced transactions: %s", err.Error())
+		return nil, err
+	}
+
+	integrity := &entity.LedgerIntegrity{
+		Balanced:               len(unbalanced) == 0,
+		Totals:                 totals,
+		UnbalancedTransactions: unbalanced,
+	}
+	for _, t := range totals {
+		if t.Debits != t.Credits {
+			integrity.Balanced = false
+		}
+	}
+
+	if !integrity.Balanced {
+		log.Printf("[CheckIntegrity] ledger does not balance: %d unbalanced transactions", len(unbalanced))
+	}
+
+	return integrity, nil
+}
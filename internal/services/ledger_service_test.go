@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+func setupLedgerService(t *testing.T) (*ledgerService, *mock.MockLedgerRepo) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockLedgerRepo(ctrl)
+
+	return NewLedgerService(repo), repo
+}
+
+func Test_ListBalances(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLedgerService(t)
+	ctx := context.Background()
+
+	t.Run("list balances failed, unknown account type", func(t *testing.T) {
+		_, err := svc.ListBalances(ctx, entity.AccountFilter{Type: "savings"})
+		assert.True(t, errors.Is(err, ErrInvalidAccountFilter))
+	})
+
+	t.Run("list balances success, reported on the normal side", func(t *testing.T) {
+		investorID, loanID := uuid.New(), uuid.New()
+		balances := []entity.AccountBalance{
+			{
+				LedgerAccount: entity.LedgerAccount{Type: entity.AccountInvestorWallet, OwnerID: investorID},
+				Debits:        entity.NewMoney(600, "IDR"),
+				Credits:       entity.NewMoney(660, "IDR"),
+			},
+			{
+				LedgerAccount: entity.LedgerAccount{Type: entity.AccountLoanReceivable, OwnerID: loanID},
+				Debits:        entity.NewMoney(1000, "IDR"),
+				Credits:       entity.NewMoney(400, "IDR"),
+			},
+		}
+
+		repo.EXPECT().ListBalances(ctx, entity.AccountFilter{}).Return(balances, nil)
+
+		res, err := svc.ListBalances(ctx, entity.AccountFilter{})
+		assert.Nil(t, err)
+		assert.Equal(t, entity.NewMoney(60, "IDR"), res[0].Balance)
+		assert.Equal(t, entity.NewMoney(600, "IDR"), res[1].Balance)
+	})
+}
+
+func Test_CheckIntegrity(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLedgerService(t)
+	ctx := context.Background()
+
+	t.Run("ledger balanced", func(t *testing.T) {
+		repo.EXPECT().LedgerTotals(ctx).Return([]entity.LedgerTotals{{Currency: "IDR", Debits: 100, Credits: 100}}, nil)
+		repo.EXPECT().ListUnbalancedTransactions(ctx).Return([]uuid.UUID{}, nil)
+
+		res, err := svc.CheckIntegrity(ctx)
+		assert.Nil(t, err)
+		assert.True(t, res.Balanced)
+	})
+
+	t.Run("ledger not balanced", func(t *testing.T) {
+		txnID := uuid.New()
+		repo.EXPECT().LedgerTotals(ctx).Return([]entity.LedgerTotals{{Currency: "IDR", Debits: 100, Credits: 90}}, nil)
+		repo.EXPECT().ListUnbalancedTransactions(ctx).Return([]uuid.UUID{txnID}, nil)
+
+		res, err := svc.CheckIntegrity(ctx)
+		assert.Nil(t, err)
+		assert.False(t, res.Balanced)
+		assert.Equal(t, []uuid.UUID{txnID}, res.UnbalancedTransactions)
+	})
+}
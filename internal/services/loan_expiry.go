@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+const (
+	defaultFundingWindowDays = 30
+
+	//expiryBatchSize caps how many loans a single ExpireOverdueLoans run handles, the rest wait for the next run
+	expiryBatchSize = 100
+)
+
+// fundingDeadline ends the funding window fundingWindowDays after the approval date
+func fundingDeadline(approvedAt time.Time, fundingWindowDays int) time.Time {
+	return approvedAt.AddDate(0, 0, fundingWindowDays)
+}
+
+// fundingClosed tells whether the loan's funding deadline has passed at now, loans without one never close
+func fundingClosed(loan *entity.Loan, now time.Time) bool {
+	return !loan.FundingDeadline.IsZero() && !now.Before(loan.FundingDeadline)
+}
+
+// ExpireOverdueLoans moves approved loans whose funding deadline has passed to expired, which gives the pledged
+// funds back to their investors. It returns how many loans were expired.
+func (s *loanService) ExpireOverdueLoans(ctx context.Context) (int, error) {
+
+	loans, err := s.repo.ListLoans(ctx, entity.LoanFilter{
+		Statuses:       []string{entity.LoanStatusApproved},
+		DeadlineBefore: time.Now(),
+		Ascending:      true,
+		Limit:          expiryBatchSize,
+	})
+	if err != nil {
+		log.Printf("[ExpireOverdueLoans] error listing overdue loans: %s", err.Error())
+		return 0, err
+	}
+
+	expired := 0
+	for _, loan := range loans {
+		if err := checkTransition(loan.Status, entity.LoanStatusExpired); err != nil {
+			continue
+		}
+
+		change := entity.LoanStatusChange{
+			LoanID: loan.ID,
+			From:   loan.Status,
+			To:     entity.LoanStatusExpired,
+			Reason: "funding deadline passed",
+		}
+
+		err := s.repo.UpdateLoanStatus(ctx, change)
+		//the loan got its last investment or was cancelled in the meantime
+		if errors.Is(err, apperror.ErrLoanConcurrentUpdate) {
+			log.Printf("[ExpireOverdueLoans] loan %s changed while expiring, skipped", loan.ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("[ExpireOverdueLoans] error expiring loan %s: %s", loan.ID, err.Error())
+			return expired, err
+		}
+
+		expired++
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func Test_FundingDeadline(t *testing.T) {
+	t.Parallel()
+
+	approvedAt := time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)
+	deadline := fundingDeadline(approvedAt, 30)
+	assert.Equal(t, time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), deadline)
+
+	loan := &entity.Loan{FundingDeadline: deadline}
+	assert.False(t, fundingClosed(loan, deadline.Add(-time.Second)))
+	assert.True(t, fundingClosed(loan, deadline))
+	assert.False(t, fundingClosed(&entity.Loan{}, deadline))
+}
+
+func Test_ExpireOverdueLoans(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+
+	overdue := func() entity.Loan {
+		return entity.Loan{ID: uuid.New(), Status: entity.LoanStatusApproved, FundingDeadline: time.Now().Add(-time.Hour)}
+	}
+	expiry := func(loan entity.Loan) entity.LoanStatusChange {
+		return entity.LoanStatusChange{LoanID: loan.ID, From: entity.LoanStatusApproved, To: entity.LoanStatusExpired, Reason: "funding deadline passed"}
+	}
+
+	t.Run("expire loans failed, error listing loans", func(t *testing.T) {
+		repo.EXPECT().ListLoans(ctx, gomock.Any()).Return(nil, errors.New("db error"))
+
+		_, err := svc.ExpireOverdueLoans(ctx)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("expire loans success, loans changed meanwhile are skipped", func(t *testing.T) {
+		first, second, third := overdue(), overdue(), overdue()
+
+		repo.EXPECT().ListLoans(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, filter entity.LoanFilter) ([]entity.Loan, error) {
+			assert.Equal(t, []string{entity.LoanStatusApproved}, filter.Statuses)
+			assert.WithinDuration(t, time.Now(), filter.DeadlineBefore, time.Minute)
+			assert.Equal(t, expiryBatchSize, filter.Limit)
+			return []entity.Loan{first, second, third}, nil
+		})
+		repo.EXPECT().UpdateLoanStatus(ctx, expiry(first)).Return(nil)
+		repo.EXPECT().UpdateLoanStatus(ctx, expiry(second)).Return(apperror.ErrLoanConcurrentUpdate)
+		repo.EXPECT().UpdateLoanStatus(ctx, expiry(third)).Return(nil)
+
+		expired, err := svc.ExpireOverdueLoans(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, expired)
+	})
+
+	t.Run("expire loans failed, error updating a loan", func(t *testing.T) {
+		loan := overdue()
+
+		repo.EXPECT().ListLoans(ctx, gomock.Any()).Return([]entity.Loan{loan}, nil)
+		repo.EXPECT().UpdateLoanStatus(ctx, expiry(loan)).Return(errors.New("db error"))
+
+		expired, err := svc.ExpireOverdueLoans(ctx)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, expired)
+	})
+}
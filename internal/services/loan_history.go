@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+// GetLoanHistory returns the timeline of the loan, oldest change first, each change with the fields it touched
+func (s *loanService) GetLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryEntry, error) {
+
+	//an unknown loan is a 404 rather than an empty timeline
+	if _, err := s.repo.GetLoanByID(ctx, loanID); err != nil {
+		log.Printf("[GetLoanHistory] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	records, err := s.repo.ListLoanHistory(ctx, loanID)
+	if err != nil {
+		log.Printf("[GetLoanHistory] error listing loan history: %s", err.Error())
+		return nil, err
+	}
+
+	entries := make([]entity.LoanHistoryEntry, 0, len(records))
+	for _, record := range records {
+		entry, err := historyEntry(record)
+		if err != nil {
+			log.Printf("[GetLoanHistory] error reading history record %d: %s", record.ID, err.Error())
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func historyEntry(record entity.LoanHistoryRecord) (entity.LoanHistoryEntry, error) {
+	before, err := decodeSnapshot(record.Before)
+	if err != nil {
+		return entity.LoanHistoryEntry{}, err
+	}
+	after, err := decodeSnapshot(record.After)
+	if err != nil {
+		return entity.LoanHistoryEntry{}, err
+	}
+
+	entry := entity.LoanHistoryEntry{
+		ID:        record.ID,
+		LoanID:    record.LoanID,
+		From:      snapshotStatus(before),
+		To:        snapshotStatus(after),
+		Actor:     historyActor(record),
+		RequestID: record.RequestID,
+		Reason:    record.Reason,
+		ChangedAt: record.UpdatedAt,
+		Changes:   diffSnapshots(before, after),
+	}
+
+	return entry, nil
+}
+
+// historyActor is who wrote the record, nil for old records that did not say
+func historyActor(record entity.LoanHistoryRecord) *entity.HistoryActor {
+	if record.ActorType == "" {
+		return nil
+	}
+
+	actor := &entity.HistoryActor{Type: record.ActorType}
+	if record.UpdatedBy.Valid {
+		actor.ID = &record.UpdatedBy.UUID
+	}
+	return actor
+}
+
+// decodeSnapshot reads a loan snapshot as a flat map of field path to value, empty for a missing snapshot
+func decodeSnapshot(raw json.RawMessage) (map[string]any, error) {
+	fields := map[string]any{}
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return fields, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber() //amounts in minor units must not go through float64
+	var snapshot map[string]any
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, err
+	}
+
+	flattenSnapshot("", snapshot, fields)
+	return fields, nil
+}
+
+func flattenSnapshot(prefix string, value map[string]any, fields map[string]any) {
+	for key, v := range value {
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flattenSnapshot(prefix+key+".", nested, fields)
+			continue
+		}
+		fields[prefix+key] = v
+	}
+}
+
+func snapshotStatus(fields map[string]any) string {
+	status, _ := fields["loan_status"].(string)
+	return status
+}
+
+// diffSnapshots lists the fields whose value differs between the two snapshots, sorted by field path.
+// Lists such as investor_returns are compared as a whole.
+func diffSnapshots(before, after map[string]any) []entity.FieldChange {
+	changes := []entity.FieldChange{}
+	for field, a := range after {
+		b, ok := before[field]
+		if !ok || !reflect.DeepEqual(a, b) {
+			changes = append(changes, entity.FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	for field, b := range before {
+		if _, ok := after[field]; !ok {
+			changes = append(changes, entity.FieldChange{Field: field, Before: b, After: nil})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
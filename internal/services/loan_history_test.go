@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func snapshot(t *testing.T, loan entity.Loan) json.RawMessage {
+	t.Helper()
+
+	raw, err := json.Marshal(loan)
+	assert.Nil(t, err)
+	return raw
+}
+
+func Test_GetLoanHistory(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+	loanID := uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b")
+	staffID := uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82")
+	investorID := uuid.MustParse("9a2f4c61-5b1e-4d0a-8f3c-2e7b6d1a0c94")
+
+	t.Run("get loan history failed, loan not found", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(nil, apperror.ErrLoanNotFound)
+
+		_, err := svc.GetLoanHistory(ctx, loanID)
+		assert.True(t, errors.Is(err, apperror.ErrLoanNotFound))
+	})
+
+	t.Run("get loan history failed, error listing history", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&entity.Loan{ID: loanID}, nil)
+		repo.EXPECT().ListLoanHistory(ctx, loanID).Return(nil, errors.New("db error"))
+
+		_, err := svc.GetLoanHistory(ctx, loanID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("get loan history success, each change with its actor and request", func(t *testing.T) {
+		approvedAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+		investedAt := time.Date(2025, 6, 3, 15, 30, 0, 0, time.UTC)
+		expiredAt := time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)
+
+		proposed := entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1050,
+			Status:          entity.LoanStatusProposed,
+			RemainingAmount: entity.NewMoney(1000000, "IDR"),
+		}
+		approved := proposed
+		approved.Status = entity.LoanStatusApproved
+		approved.UpdatedAt = approvedAt
+		approved.Approval = &entity.LoanApproval{ApprovedBy: staffID, FieldValidatorID: staffID, ApprovalDate: approvedAt}
+		invested := approved
+		invested.Status = entity.LoanStatusInvested
+		invested.UpdatedAt = investedAt
+		invested.RemainingAmount = entity.NewMoney(0, "IDR")
+		expired := invested
+		expired.Status = entity.LoanStatusExpired
+		expired.UpdatedAt = expiredAt
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&invested, nil)
+		repo.EXPECT().ListLoanHistory(ctx, loanID).Return([]entity.LoanHistoryRecord{
+			{ID: 7, LoanID: loanID, Before: snapshot(t, proposed), After: snapshot(t, approved),
+				UpdatedBy: uuid.NullUUID{UUID: staffID, Valid: true}, ActorType: entity.ActorTypeStaff, RequestID: "req-1",
+				Reason: "documents verified", UpdatedAt: approvedAt},
+			{ID: 9, LoanID: loanID, Before: snapshot(t, approved), After: snapshot(t, invested),
+				UpdatedBy: uuid.NullUUID{UUID: investorID, Valid: true}, ActorType: entity.ActorTypeInvestor, RequestID: "req-2",
+				Reason: "principal amount fully invested", UpdatedAt: investedAt},
+			{ID: 12, LoanID: loanID, Before: snapshot(t, invested), After: snapshot(t, expired),
+				ActorType: entity.ActorTypeSystem, Reason: "funding deadline passed", UpdatedAt: expiredAt},
+			{ID: 3, LoanID: loanID, Before: snapshot(t, proposed), After: snapshot(t, proposed), Reason: "written before actors were recorded"},
+		}, nil)
+
+		history, err := svc.GetLoanHistory(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Len(t, history, 4)
+
+		approval := history[0]
+		assert.Equal(t, int64(7), approval.ID)
+		assert.Equal(t, entity.LoanStatusProposed, approval.From)
+		assert.Equal(t, entity.LoanStatusApproved, approval.To)
+		assert.Equal(t, &entity.HistoryActor{Type: entity.ActorTypeStaff, ID: &staffID}, approval.Actor)
+		assert.Equal(t, "req-1", approval.RequestID)
+		assert.Equal(t, approvedAt, approval.ChangedAt)
+		assert.Equal(t, "documents verified", approval.Reason)
+
+		fields := []string{}
+		for _, change := range approval.Changes {
+			fields = append(fields, change.Field)
+		}
+		assert.Equal(t, []string{"approval.approval_date", "approval.approved_by", "approval.field_validator_id",
+			"approval.picture_proof", "loan_status", "updated_at"}, fields)
+		assert.Equal(t, entity.FieldChange{Field: "approval.approved_by", Before: nil, After: staffID.String()}, approval.Changes[1])
+		assert.Equal(t, entity.FieldChange{Field: "loan_status", Before: "proposed", After: "approved"}, approval.Changes[4])
+
+		funding := history[1]
+		assert.Equal(t, &entity.HistoryActor{Type: entity.ActorTypeInvestor, ID: &investorID}, funding.Actor)
+		assert.Equal(t, entity.LoanStatusInvested, funding.To)
+		assert.Contains(t, funding.Changes, entity.FieldChange{
+			Field: "remaining_amount.amount", Before: json.Number("1000000"), After: json.Number("0"),
+		})
+
+		expiry := history[2]
+		assert.Equal(t, &entity.HistoryActor{Type: entity.ActorTypeSystem}, expiry.Actor)
+		assert.Empty(t, expiry.RequestID)
+
+		assert.Nil(t, history[3].Actor)
+	})
+
+	t.Run("get loan history success, a repayment is one record naming its allocation to the instalments", func(t *testing.T) {
+		repaidAt := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+		disbursed := entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1050,
+			Status:          entity.LoanStatusDisbursed,
+			RemainingAmount: entity.NewMoney(0, "IDR"),
+		}
+		repaying := disbursed
+		repaying.Status = entity.LoanStatusRepaying
+		repaying.UpdatedAt = repaidAt
+		repayment := entity.Repayment{
+			ID:     uuid.MustParse("5d0c7e2a-8b4f-4c1d-9e3a-7f6b2c1d0e9f"),
+			LoanID: loanID,
+			Amount: entity.NewMoney(100000, "IDR"),
+			Allocations: []entity.RepaymentAllocation{
+				{InstalmentNumber: 1, Fee: entity.NewMoney(0, "IDR"), Interest: entity.NewMoney(8750, "IDR"), Principal: entity.NewMoney(83333, "IDR")},
+				{InstalmentNumber: 2, Fee: entity.NewMoney(0, "IDR"), Interest: entity.NewMoney(7917, "IDR"), Principal: entity.NewMoney(0, "IDR")},
+			},
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&repaying, nil)
+		repo.EXPECT().ListLoanHistory(ctx, loanID).Return([]entity.LoanHistoryRecord{
+			{ID: 21, LoanID: loanID, Before: snapshot(t, disbursed), After: snapshot(t, repaying),
+				UpdatedBy: uuid.NullUUID{UUID: staffID, Valid: true}, ActorType: entity.ActorTypeStaff, RequestID: "req-3",
+				Reason: "first repayment received: " + repayment.Summary(), UpdatedAt: repaidAt},
+		}, nil)
+
+		history, err := svc.GetLoanHistory(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Len(t, history, 1)
+
+		entry := history[0]
+		assert.Equal(t, entity.LoanStatusDisbursed, entry.From)
+		assert.Equal(t, entity.LoanStatusRepaying, entry.To)
+		assert.Equal(t, &entity.HistoryActor{Type: entity.ActorTypeStaff, ID: &staffID}, entry.Actor)
+		assert.Equal(t, "first repayment received: repayment 5d0c7e2a-8b4f-4c1d-9e3a-7f6b2c1d0e9f of 100000 IDR recorded, "+
+			"allocated to instalment 1 (fee 0, interest 8750, principal 83333), instalment 2 (fee 0, interest 7917, principal 0)", entry.Reason)
+		assert.Contains(t, entry.Changes, entity.FieldChange{Field: "loan_status", Before: "disbursed", After: "repaying"})
+	})
+}
+
+func Test_DiffSnapshots(t *testing.T) {
+	t.Parallel()
+
+	before, err := decodeSnapshot(json.RawMessage(`{"loan_status":"approved","approval":{"approved_by":"a"},"agreement_letter":""}`))
+	assert.Nil(t, err)
+	after, err := decodeSnapshot(json.RawMessage(`{"loan_status":"approved","approval":{"approved_by":"b"},"returns":{"amount":5}}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []entity.FieldChange{
+		{Field: "agreement_letter", Before: "", After: nil},
+		{Field: "approval.approved_by", Before: "a", After: "b"},
+		{Field: "returns.amount", Before: nil, After: json.Number("5")},
+	}, diffSnapshots(before, after))
+
+	//the first record of a loan has no before snapshot
+	empty, err := decodeSnapshot(json.RawMessage(`null`))
+	assert.Nil(t, err)
+	assert.Len(t, diffSnapshots(empty, after), 3)
+}
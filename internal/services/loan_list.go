@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+var (
+	ErrInvalidLoanFilter = apperror.Validation("invalid_filter", "loan filter is invalid")
+	ErrInvalidCursor     = apperror.Validation("invalid_cursor", "cursor is invalid")
+)
+
+// knownStatuses is every status a loan can be in, used to validate status filters
+var knownStatuses = map[string]bool{
+	entity.LoanStatusProposed:  true,
+	entity.LoanStatusApproved:  true,
+	entity.LoanStatusInvested:  true,
+	entity.LoanStatusDisbursed: true,
+	entity.LoanStatusRejected:  true,
+	entity.LoanStatusCancelled: true,
+	entity.LoanStatusExpired:   true,
+	entity.LoanStatusRepaying:  true,
+	entity.LoanStatusClosed:    true,
+}
+
+func (s *loanService) ListLoans(ctx context.Context, loanListRequest entity.LoanListRequest) (*entity.LoanPage, error) {
+
+	filter, err := loanFilter(loanListRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	//fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	loans, err := s.repo.ListLoans(ctx, filter)
+	if err != nil {
+		log.Printf("[ListLoans] error listing loans: %s", err.Error())
+		return nil, err
+	}
+
+	page := &entity.LoanPage{Loans: loans}
+	if len(loans) > limit {
+		page.Loans = loans[:limit]
+		last := page.Loans[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	for i := range page.Loans {
+		s.signLoanDocuments(ctx, &page.Loans[i])
+	}
+
+	return page, nil
+}
+
+func loanFilter(req entity.LoanListRequest) (entity.LoanFilter, error) {
+	filter := entity.LoanFilter{
+		Currency:      req.Currency,
+		MinPrincipal:  req.MinPrincipal,
+		MaxPrincipal:  req.MaxPrincipal,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Limit:         req.Limit,
+	}
+
+	//status accepts both repeated params and a comma separated list
+	for _, raw := range req.Statuses {
+		for _, status := range strings.Split(raw, ",") {
+			if !knownStatuses[status] {
+				return filter, ErrInvalidLoanFilter.Withf("unknown loan status %q", status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if req.BorrowerID != "" {
+		borrowerID, err := uuid.Parse(req.BorrowerID)
+		if err != nil {
+			return filter, ErrInvalidLoanFilter.Withf("borrower_id must be a UUID")
+		}
+		filter.BorrowerID = borrowerID
+	}
+
+	if req.Currency != "" {
+		if err := entity.NewMoney(0, req.Currency).Validate(); err != nil {
+			return filter, ErrInvalidLoanFilter.Withf("currency: %s", err.Error())
+		}
+	}
+
+	if req.MinPrincipal != nil && req.MaxPrincipal != nil && *req.MinPrincipal > *req.MaxPrincipal {
+		return filter, ErrInvalidLoanFilter.Withf("min_principal is greater than max_principal")
+	}
+	if req.MinInterestRate != "" {
+		rate, err := entity.ParseRate(req.MinInterestRate)
+		if err != nil {
+			return filter, ErrInvalidLoanFilter.Withf("min_interest_rate: %s", err.Error())
+		}
+		filter.MinInterestRate = &rate
+	}
+	if req.MaxInterestRate != "" {
+		rate, err := entity.ParseRate(req.MaxInterestRate)
+		if err != nil {
+			return filter, ErrInvalidLoanFilter.Withf("max_interest_rate: %s", err.Error())
+		}
+		filter.MaxInterestRate = &rate
+	}
+	if filter.MinInterestRate != nil && filter.MaxInterestRate != nil && *filter.MinInterestRate > *filter.MaxInterestRate {
+		return filter, ErrInvalidLoanFilter.Withf("min_interest_rate is greater than max_interest_rate")
+	}
+
+	switch req.Sort {
+	case "", "-created_at":
+		filter.Ascending = false
+	case "created_at":
+		filter.Ascending = true
+	default:
+		return filter, ErrInvalidLoanFilter.Withf("sort must be created_at or -created_at")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	} else if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if req.Cursor != "" {
+		createdAt, id, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return filter, ErrInvalidCursor
+		}
+		filter.AfterCreatedAt = createdAt
+		filter.AfterID = id
+	}
+
+	return filter, nil
+}
+
+// encodeCursor turns the keyset (created_at, loan_id) of the last row into an opaque token
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := fmt.Sprintf("%s|%s", createdAt.UTC().Format(time.RFC3339Nano), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	createdAtRaw, idRaw, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtRaw)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(idRaw)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	return createdAt, id, nil
+}
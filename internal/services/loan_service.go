@@ -2,10 +2,11 @@ package services
 
 import (
 	"context"
-	"errors"
 	"log"
+	"time"
 
 	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
 	"github.com/google/uuid"
 )
 
@@ -15,36 +16,104 @@ type (
 		CreateLoan(ctx context.Context, loanRequest entity.LoanSubmitRequest) (*entity.Loan, error)
 		UpdateLoan(ctx context.Context, loanStatusRequest entity.LoanUpdateRequest) error
 		InvestLoan(ctx context.Context, loanInvestRequest entity.LoanInvestRequest) error
+		WithdrawInvestment(ctx context.Context, loanWithdrawRequest entity.LoanWithdrawRequest) (*entity.InvestmentWithdrawal, error)
 		DisburseLoan(ctx context.Context, loanDisburseRequest entity.LoanDisburseRequest) error
 		GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error)
+		ListLoans(ctx context.Context, loanListRequest entity.LoanListRequest) (*entity.LoanPage, error)
+		ListLoanInvestments(ctx context.Context, loanID uuid.UUID) ([]entity.InvestmentDetail, error)
+		ListInvestorInvestments(ctx context.Context, investorID uuid.UUID) ([]entity.InvestmentDetail, error)
+		GetLoanSchedule(ctx context.Context, loanID uuid.UUID) (*entity.LoanSchedule, error)
+		RepayLoan(ctx context.Context, loanRepaymentRequest entity.LoanRepaymentRequest) (*entity.Repayment, error)
+		ListInvestorPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error)
+		ExpireOverdueLoans(ctx context.Context) (int, error)
+		IssueAgreement(ctx context.Context, loanID uuid.UUID, actor entity.Actor) (*entity.LoanAgreement, error)
+		GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error)
+		GetLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryEntry, error)
 	}
 
 	loanService struct {
 		repo LoanRepo
+
+		firstDueAfterMonths int
+		fundingWindowDays   int
+		limits              InvestmentLimits
+		documents           DocumentStore
+		documentURLTTL      time.Duration
 	}
 
+	Option func(*loanService)
+
 	LoanRepo interface {
 		InsertLoan(ctx context.Context, loan *entity.Loan) (*entity.Loan, error)
-		UpdateLoanStatus(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error
-		AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment) error
-		DisburseLoan(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error
+		UpdateLoanStatus(ctx context.Context, change entity.LoanStatusChange) error
+		AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment, checkInvestor func(entity.InvestorTotals) error) (bool, error)
+		WithdrawInvestment(ctx context.Context, withdrawal *entity.InvestmentWithdrawal) error
+		DisburseLoan(ctx context.Context, loan *entity.Loan, schedule []entity.LoanInstalment, staffID uuid.UUID) error
 		GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error)
+		ListLoans(ctx context.Context, filter entity.LoanFilter) ([]entity.Loan, error)
+		ListInvestments(ctx context.Context, filter entity.InvestmentFilter) ([]entity.InvestmentDetail, error)
+		ListInstalments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanInstalment, error)
+		RecordRepayment(ctx context.Context, record *entity.RepaymentRecord) error
+		ListPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error)
+		SaveAgreement(ctx context.Context, agreement *entity.LoanAgreement, actor entity.Actor) error
+		GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error)
+		ListLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryRecord, error)
 	}
 )
 
-func NewLoanService(repo LoanRepo) *loanService {
-	return &loanService{
-		repo: repo,
+// WithFirstDueAfterMonths sets how many months after disbursement the first instalment is due
+func WithFirstDueAfterMonths(months int) Option {
+	return func(s *loanService) {
+		if months > 0 {
+			s.firstDueAfterMonths = months
+		}
+	}
+}
+
+// WithFundingWindowDays sets how many days after approval a loan stays open for investment
+func WithFundingWindowDays(days int) Option {
+	return func(s *loanService) {
+		if days > 0 {
+			s.fundingWindowDays = days
+		}
 	}
 }
 
+func NewLoanService(repo LoanRepo, opts ...Option) *loanService {
+	s := &loanService{
+		repo:                repo,
+		firstDueAfterMonths: defaultFirstDueAfterMonths,
+		fundingWindowDays:   defaultFundingWindowDays,
+		documentURLTTL:      defaultDocumentURLTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 func (s *loanService) CreateLoan(ctx context.Context, loanRequest entity.LoanSubmitRequest) (*entity.Loan, error) {
 
+	if err := validateAmount(loanRequest.PrincipalAmount); err != nil {
+		return nil, err
+	}
+	if loanRequest.InterestRate <= 0 {
+		return nil, apperror.ErrInvalidRate
+	}
+	if loanRequest.RepaymentMethod == "" {
+		loanRequest.RepaymentMethod = entity.RepaymentMethodFlat
+	}
+	if err := validateTerms(loanRequest.TenorMonths, loanRequest.RepaymentMethod); err != nil {
+		return nil, err
+	}
+
 	loan := entity.Loan{
 		ID:              uuid.New(),
 		BorrowerID:      uuid.MustParse(loanRequest.BorrowerID),
 		PrincipalAmount: loanRequest.PrincipalAmount,
 		InterestRate:    loanRequest.InterestRate,
+		TenorMonths:     loanRequest.TenorMonths,
+		RepaymentMethod: loanRequest.RepaymentMethod,
 	}
 
 	res, err := s.repo.InsertLoan(ctx, &loan)
@@ -57,32 +126,159 @@ func (s *loanService) CreateLoan(ctx context.Context, loanRequest entity.LoanSub
 
 func (s *loanService) UpdateLoan(ctx context.Context, loanStatusRequest entity.LoanUpdateRequest) error {
 
-	loan := entity.Loan{
-		ID:     uuid.MustParse(loanStatusRequest.LoanID),
-		Status: loanStatusRequest.Status,
+	loanID, err := uuid.Parse(loanStatusRequest.LoanID)
+	if err != nil {
+		return apperror.ErrInvalidLoanID
 	}
 
-	err := s.repo.UpdateLoanStatus(ctx, &loan, uuid.MustParse(loanStatusRequest.StaffID))
+	currentLoan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		log.Printf("[UpdateLoan] error getting loan detail: %s", err.Error())
+		return err
+	}
+
+	if err := checkStaffTransition(currentLoan.Status, loanStatusRequest.Status); err != nil {
+		return err
+	}
+
+	change := entity.LoanStatusChange{
+		LoanID:    loanID,
+		From:      currentLoan.Status,
+		To:        loanStatusRequest.Status,
+		UpdatedBy: uuid.MustParse(loanStatusRequest.StaffID),
+		Reason:    loanStatusRequest.Reason,
+	}
+
+	//approval is only accepted together with the field validator's visit evidence
+	if change.To == entity.LoanStatusApproved {
+		if loanStatusRequest.PictureProof != nil {
+			loanStatusRequest.PictureProofLink, err = s.storeDocument(ctx, documentKindApproval, loanStatusRequest.PictureProof)
+			if err != nil {
+				log.Printf("[UpdateLoan] error storing picture proof: %s", err.Error())
+				return err
+			}
+		}
+		change.Approval, err = approvalEvidence(loanStatusRequest, loanID, change.UpdatedBy)
+		if err != nil {
+			return err
+		}
+		change.FundingDeadline = fundingDeadline(change.Approval.ApprovalDate, s.fundingWindowDays)
+	}
+
+	err = s.repo.UpdateLoanStatus(ctx, change)
 	if err != nil {
 		log.Printf("[UpdateLoan] error update loan: %s", err.Error())
 	}
 	return err
 }
 
+// validateAmount accepts strictly positive amounts with a well formed currency
+func validateAmount(amount entity.Money) error {
+	if !amount.IsPositive() || amount.Validate() != nil {
+		return apperror.ErrInvalidAmount
+	}
+	return nil
+}
+
+func approvalEvidence(req entity.LoanUpdateRequest, loanID, staffID uuid.UUID) (*entity.LoanApproval, error) {
+	fieldValidatorID, err := uuid.Parse(req.FieldValidatorID)
+	if err != nil || req.PictureProofLink == "" || req.ApprovedAt.IsZero() {
+		return nil, apperror.ErrMissingApprovalEvidence
+	}
+
+	return &entity.LoanApproval{
+		LoanID:           loanID,
+		PictureProof:     req.PictureProofLink,
+		FieldValidatorID: fieldValidatorID,
+		ApprovedBy:       staffID,
+		ApprovalDate:     req.ApprovedAt,
+	}, nil
+}
+
 func (s *loanService) InvestLoan(ctx context.Context, loanInvestRequest entity.LoanInvestRequest) error {
 
+	loanID, err := uuid.Parse(loanInvestRequest.LoanID)
+	if err != nil {
+		return apperror.ErrInvalidLoanID
+	}
+
 	investment := entity.LoanInvestment{
-		LoanID:     uuid.MustParse(loanInvestRequest.LoanID),
+		LoanID:     loanID,
 		Amount:     loanInvestRequest.Amount,
 		InvestorID: loanInvestRequest.InvestorID,
 	}
 
-	err := s.repo.AddLoanInvestments(ctx, investment)
+	if err := validateAmount(investment.Amount); err != nil {
+		return err
+	}
+
+	currentLoan, err := s.repo.GetLoanByID(ctx, investment.LoanID)
+	if err != nil {
+		log.Printf("[InvestLoan] error getting loan detail: %s", err.Error())
+		return err
+	}
+
+	if err := checkInvestable(currentLoan.Status); err != nil {
+		return err
+	}
+
+	if fundingClosed(currentLoan, time.Now()) {
+		return apperror.ErrFundingDeadlinePassed
+	}
+
+	if !investment.Amount.SameCurrency(currentLoan.PrincipalAmount) {
+		return apperror.ErrCurrencyMismatch
+	}
+
+	if err := s.checkInvestmentLimits(currentLoan, investment); err != nil {
+		return err
+	}
+
+	//the per investor rules depend on the investor's other pledges, they are checked where those are locked
+	fullyInvested, err := s.repo.AddLoanInvestments(ctx, investment, s.investorLimitsCheck(currentLoan, investment))
 	if err != nil {
 		log.Printf("[InvestLoan] error invest loan: %s", err.Error())
+		return err
 	}
 
-	return err
+	//the pledge completing the loan, as found under the loan lock, issues its agreement letter to every investor. The
+	//pledge itself is committed, so a failure here is only logged: the letter can be issued again by staff and is
+	//issued at disbursement otherwise.
+	if fullyInvested {
+		if _, err := s.IssueAgreement(ctx, investment.LoanID, entity.SystemActor); err != nil {
+			log.Printf("[InvestLoan] error issuing agreement letter: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *loanService) WithdrawInvestment(ctx context.Context, loanWithdrawRequest entity.LoanWithdrawRequest) (*entity.InvestmentWithdrawal, error) {
+
+	withdrawal := entity.InvestmentWithdrawal{
+		LoanID:       uuid.MustParse(loanWithdrawRequest.LoanID),
+		InvestmentID: uuid.MustParse(loanWithdrawRequest.InvestmentID),
+		InvestorID:   loanWithdrawRequest.InvestorID,
+	}
+
+	currentLoan, err := s.repo.GetLoanByID(ctx, withdrawal.LoanID)
+	if err != nil {
+		log.Printf("[WithdrawInvestment] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	//a pledge can be taken back only as long as the loan may still receive investments
+	if err := checkInvestable(currentLoan.Status); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithdrawInvestment(ctx, &withdrawal)
+	if err != nil {
+		log.Printf("[WithdrawInvestment] error withdrawing investment: %s", err.Error())
+		return nil, err
+	}
+
+	return &withdrawal, nil
 }
 
 func (s *loanService) GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error) {
@@ -90,33 +286,143 @@ func (s *loanService) GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entit
 	if err != nil {
 		return nil, err
 	}
-	loan.Returns = float64(loan.InterestRate/100) * float64(loan.PrincipalAmount)
+
+	investments, err := s.repo.ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID})
+	if err != nil {
+		log.Printf("[GetLoanByID] error listing investments: %s", err.Error())
+		return nil, err
+	}
+
+	loanInvestments := make([]entity.LoanInvestment, len(investments))
+	for i, inv := range investments {
+		loanInvestments[i] = inv.LoanInvestment
+	}
+
+	loan.Returns = totalInterest(loan.PrincipalAmount, loan.InterestRate, loan.TenorMonths, loan.RepaymentMethod)
+	loan.InvestorReturns = investorReturns(loan.PrincipalAmount, loan.Returns, loanInvestments)
+	s.signLoanDocuments(ctx, loan)
 	return loan, err
 
 }
 
 func (s *loanService) DisburseLoan(ctx context.Context, loanDisburseRequest entity.LoanDisburseRequest) error {
 
+	loanID, err := uuid.Parse(loanDisburseRequest.LoanID)
+	if err != nil {
+		return apperror.ErrInvalidLoanID
+	}
+
 	loan := entity.Loan{
-		ID:              uuid.MustParse(loanDisburseRequest.LoanID),
-		Status:          "disbursed",
+		ID:              loanID,
+		Status:          entity.LoanStatusDisbursed,
 		AgreementLetter: loanDisburseRequest.AgreementLetterLink,
 		DisburseAt:      loanDisburseRequest.DisburseAt,
 	}
 
-	currentLoan, err := s.GetLoanByID(ctx, uuid.MustParse(loanDisburseRequest.LoanID))
+	currentLoan, err := s.repo.GetLoanByID(ctx, loanID)
 	if err != nil {
 		log.Printf("[DisburseLoan] error getting loan detail: %s", err.Error())
 		return err
 	}
 
-	if currentLoan.Status != "invested" {
-		return errors.New("loan principal amount is not met yet")
+	if err := checkTransition(currentLoan.Status, entity.LoanStatusDisbursed); err != nil {
+		return err
+	}
+
+	if loanDisburseRequest.LoanAgreementDocs != nil {
+		loan.AgreementLetter, err = s.storeDocument(ctx, documentKindAgreement, loanDisburseRequest.LoanAgreementDocs)
+		if err != nil {
+			log.Printf("[DisburseLoan] error storing agreement letter: %s", err.Error())
+			return err
+		}
+	}
+
+	//without a signed copy uploaded, the loan is disbursed with its generated agreement letter
+	if loan.AgreementLetter == "" {
+		loan.AgreementLetter = currentLoan.AgreementLetter
+	}
+	if loan.AgreementLetter == "" {
+		agreement, err := s.IssueAgreement(ctx, currentLoan.ID, entity.StaffActor(uuid.MustParse(loanDisburseRequest.StaffID)))
+		if err != nil {
+			return err
+		}
+		loan.AgreementLetter = agreement.Link
 	}
 
-	err = s.repo.DisburseLoan(ctx, &loan, uuid.MustParse(loanDisburseRequest.StaffID))
+	//the schedule is stored together with the disbursement so a disbursed loan always has one
+	schedule := repaymentSchedule(currentLoan, loan.DisburseAt, s.firstDueAfterMonths)
+
+	err = s.repo.DisburseLoan(ctx, &loan, schedule, uuid.MustParse(loanDisburseRequest.StaffID))
 	if err != nil {
 		log.Printf("[DisburseLoan] error disburse loan: %s", err.Error())
 	}
 	return err
 }
+
+func (s *loanService) ListLoanInvestments(ctx context.Context, loanID uuid.UUID) ([]entity.InvestmentDetail, error) {
+
+	//surface a missing loan as such instead of an empty list
+	if _, err := s.repo.GetLoanByID(ctx, loanID); err != nil {
+		log.Printf("[ListLoanInvestments] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	investments, err := s.repo.ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID})
+	if err != nil {
+		log.Printf("[ListLoanInvestments] error listing investments: %s", err.Error())
+		return nil, err
+	}
+
+	investments = withReturns(investments)
+	s.signInvestmentDocuments(ctx, investments)
+	return investments, nil
+}
+
+func (s *loanService) ListInvestorInvestments(ctx context.Context, investorID uuid.UUID) ([]entity.InvestmentDetail, error) {
+
+	//returns are split across all investments of a loan, so co-investors are loaded too and filtered out afterwards
+	investments, err := s.repo.ListInvestments(ctx, entity.InvestmentFilter{InvestorID: investorID, CoInvestors: true})
+	if err != nil {
+		log.Printf("[ListInvestorInvestments] error listing investments: %s", err.Error())
+		return nil, err
+	}
+
+	own := []entity.InvestmentDetail{}
+	for _, inv := range withReturns(investments) {
+		if inv.InvestorID == investorID {
+			own = append(own, inv)
+		}
+	}
+
+	s.signInvestmentDocuments(ctx, own)
+	return own, nil
+}
+
+// withReturns fills in each investment's share of the principal and its expected return.
+// investments are grouped per loan and keep their order, which decides rounding ties.
+func withReturns(investments []entity.InvestmentDetail) []entity.InvestmentDetail {
+	byLoan := map[uuid.UUID][]int{}
+	for i, inv := range investments {
+		byLoan[inv.LoanID] = append(byLoan[inv.LoanID], i)
+	}
+
+	for _, indexes := range byLoan {
+		first := investments[indexes[0]]
+
+		loanInvestments := make([]entity.LoanInvestment, len(indexes))
+		for j, i := range indexes {
+			loanInvestments[j] = investments[i].LoanInvestment
+		}
+
+		interest := totalInterest(first.PrincipalAmount, first.InterestRate, first.TenorMonths, first.RepaymentMethod)
+		for j, ret := range investorReturns(first.PrincipalAmount, interest, loanInvestments) {
+			inv := &investments[indexes[j]]
+			inv.ShareOfPrincipal = shareOf(inv.Amount, inv.PrincipalAmount)
+			inv.PrincipalShare = ret.PrincipalShare
+			inv.InterestShare = ret.InterestShare
+			inv.ProjectedReturn = ret.TotalReturn
+		}
+	}
+
+	return investments
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
 	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
 	gomock "github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -33,8 +35,9 @@ func Test_CreateLoan(t *testing.T) {
 	t.Run("create loan failed, error insert loan data to DB", func(t *testing.T) {
 
 		loanReq := entity.LoanSubmitRequest{
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     12,
 			Reason:          "business reason",
 			BorrowerID:      "d149aaa5-e7e8-4820-93a0-e278dcde447a",
 		}
@@ -43,11 +46,24 @@ func Test_CreateLoan(t *testing.T) {
 		_, err := svc.CreateLoan(ctx, loanReq)
 		assert.Equal(t, err.Error(), "error db")
 	})
+	t.Run("create loan failed, invalid tenor", func(t *testing.T) {
+
+		loanReq := entity.LoanSubmitRequest{
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     0,
+			BorrowerID:      "d149aaa5-e7e8-4820-93a0-e278dcde447a",
+		}
+
+		_, err := svc.CreateLoan(ctx, loanReq)
+		assert.True(t, errors.Is(err, apperror.ErrInvalidTenor))
+	})
 	t.Run("create loan success", func(t *testing.T) {
 
 		loanReq := entity.LoanSubmitRequest{
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     12,
 			Reason:          "business reason",
 			BorrowerID:      "d149aaa5-e7e8-4820-93a0-e278dcde447a",
 		}
@@ -55,8 +71,8 @@ func Test_CreateLoan(t *testing.T) {
 		loanData := entity.Loan{
 			ID:              uuid.MustParse("a98ba4bd-1e09-4134-b244-d89f9a86a44c"),
 			BorrowerID:      uuid.MustParse("d149aaa5-e7e8-4820-93a0-e278dcde447a"),
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
 			Status:          "proposed",
 		}
 
@@ -73,37 +89,125 @@ func Test_UpdateLoan(t *testing.T) {
 	svc, repo := setupLoanService(t)
 	ctx := context.Background()
 
-	t.Run("upload loan status failed, error db", func(t *testing.T) {
+	t.Run("update loan status failed, loan ID is not a UUID", func(t *testing.T) {
+		err := svc.UpdateLoan(ctx, entity.LoanUpdateRequest{LoanID: "not-a-uuid", Status: "approved"})
+		assert.True(t, errors.Is(err, apperror.ErrInvalidLoanID))
+	})
+
+	t.Run("update loan status failed, error getting loan detail", func(t *testing.T) {
+		loanUpdateReq := entity.LoanUpdateRequest{
+			LoanID:  "3e6a779e-d857-4ad3-af95-693d16e6f6d1",
+			Status:  "approved",
+			StaffID: "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, uuid.MustParse("3e6a779e-d857-4ad3-af95-693d16e6f6d1")).Return(nil, errors.New("db error"))
+
+		err := svc.UpdateLoan(ctx, loanUpdateReq)
+		assert.Equal(t, err.Error(), "db error")
+	})
+
+	t.Run("update loan status failed, illegal transition", func(t *testing.T) {
 		loan := entity.Loan{
 			ID:     uuid.MustParse("3e6a779e-d857-4ad3-af95-693d16e6f6d1"),
-			Status: "approved",
+			Status: "proposed",
 		}
 
 		loanUpdateReq := entity.LoanUpdateRequest{
 			LoanID:  "3e6a779e-d857-4ad3-af95-693d16e6f6d1",
-			Status:  "approved",
+			Status:  "disbursed",
 			StaffID: "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
 		}
 
-		repo.EXPECT().UpdateLoanStatus(ctx, &loan, uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82")).Return(errors.New("db error"))
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+
+		err := svc.UpdateLoan(ctx, loanUpdateReq)
+		var transitionErr *InvalidTransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, transitionErr.From, "proposed")
+		assert.Equal(t, transitionErr.To, "disbursed")
+	})
+
+	t.Run("update loan status failed, approval evidence is missing", func(t *testing.T) {
+		loan := entity.Loan{
+			ID:     uuid.MustParse("3e6a779e-d857-4ad3-af95-693d16e6f6d1"),
+			Status: "proposed",
+		}
+
+		loanUpdateReq := entity.LoanUpdateRequest{
+			LoanID:           "3e6a779e-d857-4ad3-af95-693d16e6f6d1",
+			Status:           "approved",
+			FieldValidatorID: "a7b1b1d4-42a4-4a5c-9bb5-0c3d8d0a6a11",
+			StaffID:          "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+
+		err := svc.UpdateLoan(ctx, loanUpdateReq)
+		assert.True(t, errors.Is(err, apperror.ErrMissingApprovalEvidence))
+	})
+
+	t.Run("update loan status failed, error db", func(t *testing.T) {
+		loan := entity.Loan{
+			ID:     uuid.MustParse("3e6a779e-d857-4ad3-af95-693d16e6f6d1"),
+			Status: "proposed",
+		}
+
+		approvedAt := time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)
+		loanUpdateReq := entity.LoanUpdateRequest{
+			LoanID:           "3e6a779e-d857-4ad3-af95-693d16e6f6d1",
+			Status:           "approved",
+			FieldValidatorID: "a7b1b1d4-42a4-4a5c-9bb5-0c3d8d0a6a11",
+			PictureProofLink: "./uploads/approvals/visit.jpg",
+			ApprovedAt:       approvedAt,
+			StaffID:          "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
+		}
+
+		change := entity.LoanStatusChange{
+			LoanID:    loan.ID,
+			From:      "proposed",
+			To:        "approved",
+			UpdatedBy: uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82"),
+			Approval: &entity.LoanApproval{
+				LoanID:           loan.ID,
+				PictureProof:     "./uploads/approvals/visit.jpg",
+				FieldValidatorID: uuid.MustParse("a7b1b1d4-42a4-4a5c-9bb5-0c3d8d0a6a11"),
+				ApprovedBy:       uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82"),
+				ApprovalDate:     approvedAt,
+			},
+			FundingDeadline: time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC),
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().UpdateLoanStatus(ctx, change).Return(errors.New("db error"))
 
 		err := svc.UpdateLoan(ctx, loanUpdateReq)
 		assert.Equal(t, err.Error(), "db error")
 	})
 
-	t.Run("upload loan status success", func(t *testing.T) {
+	t.Run("update loan status success", func(t *testing.T) {
 		loan := entity.Loan{
 			ID:     uuid.MustParse("3e6a779e-d857-4ad3-af95-693d16e6f6d1"),
-			Status: "approved",
+			Status: "proposed",
 		}
 
 		loanUpdateReq := entity.LoanUpdateRequest{
 			LoanID:  "3e6a779e-d857-4ad3-af95-693d16e6f6d1",
-			Status:  "approved",
+			Status:  "rejected",
+			Reason:  "incomplete documents",
 			StaffID: "1e938a3c-3752-49a6-a2a6-43be38c6aa82",
 		}
 
-		repo.EXPECT().UpdateLoanStatus(ctx, &loan, uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82")).Return(nil)
+		change := entity.LoanStatusChange{
+			LoanID:    loan.ID,
+			From:      "proposed",
+			To:        "rejected",
+			UpdatedBy: uuid.MustParse("1e938a3c-3752-49a6-a2a6-43be38c6aa82"),
+			Reason:    "incomplete documents",
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().UpdateLoanStatus(ctx, change).Return(nil)
 
 		err := svc.UpdateLoan(ctx, loanUpdateReq)
 		assert.Nil(t, err)
@@ -113,15 +217,20 @@ func Test_UpdateLoan(t *testing.T) {
 func Test_InvestLoan(t *testing.T) {
 	t.Parallel()
 
-	svc, repo := setupLoanService(t)
+	svc, repo, store := setupDocumentStore(t)
 	ctx := context.Background()
 
+	t.Run("invest loan failed, loan ID is not a UUID", func(t *testing.T) {
+		err := svc.InvestLoan(ctx, entity.LoanInvestRequest{LoanID: "not-a-uuid", Amount: entity.NewMoney(500000, "IDR")})
+		assert.True(t, errors.Is(err, apperror.ErrInvalidLoanID))
+	})
+
 	t.Run("invest loan failed, error when adding records to db", func(t *testing.T) {
 
 		loanInvestReq := entity.LoanInvestRequest{
 			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
 			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
-			Amount:     500000,
+			Amount:     entity.NewMoney(500000, "IDR"),
 		}
 
 		investment := entity.LoanInvestment{
@@ -130,18 +239,86 @@ func Test_InvestLoan(t *testing.T) {
 			LoanID:     uuid.MustParse(loanInvestReq.LoanID),
 		}
 
-		repo.EXPECT().AddLoanInvestments(ctx, investment).Return(errors.New("error adding db records"))
+		loan := entity.Loan{
+			ID:              investment.LoanID,
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&loan, nil)
+		repo.EXPECT().AddLoanInvestments(ctx, investment, gomock.Nil()).Return(false, errors.New("error adding db records"))
 
 		err := svc.InvestLoan(ctx, loanInvestReq)
 		assert.Equal(t, err.Error(), "error adding db records")
 	})
 
+	t.Run("invest loan failed, loan is not approved", func(t *testing.T) {
+
+		loanInvestReq := entity.LoanInvestRequest{
+			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+			Amount:     entity.NewMoney(500000, "IDR"),
+		}
+
+		loan := entity.Loan{
+			ID:     uuid.MustParse(loanInvestReq.LoanID),
+			Status: "proposed",
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+
+		err := svc.InvestLoan(ctx, loanInvestReq)
+		var transitionErr *InvalidTransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+	})
+
+	t.Run("invest loan failed, currency differs from the loan", func(t *testing.T) {
+
+		loanInvestReq := entity.LoanInvestRequest{
+			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+			Amount:     entity.NewMoney(500, "USD"),
+		}
+
+		loan := entity.Loan{
+			ID:              uuid.MustParse(loanInvestReq.LoanID),
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+
+		err := svc.InvestLoan(ctx, loanInvestReq)
+		assert.True(t, errors.Is(err, apperror.ErrCurrencyMismatch))
+	})
+
+	t.Run("invest loan failed, funding deadline passed", func(t *testing.T) {
+
+		loanInvestReq := entity.LoanInvestRequest{
+			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+			Amount:     entity.NewMoney(500000, "IDR"),
+		}
+
+		loan := entity.Loan{
+			ID:              uuid.MustParse(loanInvestReq.LoanID),
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			FundingDeadline: time.Now().Add(-time.Hour),
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+
+		err := svc.InvestLoan(ctx, loanInvestReq)
+		assert.True(t, errors.Is(err, apperror.ErrFundingDeadlinePassed))
+	})
+
 	t.Run("invest loan success", func(t *testing.T) {
 
 		loanInvestReq := entity.LoanInvestRequest{
 			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
 			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
-			Amount:     500000,
+			Amount:     entity.NewMoney(500000, "IDR"),
 		}
 
 		investment := entity.LoanInvestment{
@@ -150,19 +327,154 @@ func Test_InvestLoan(t *testing.T) {
 			LoanID:     uuid.MustParse(loanInvestReq.LoanID),
 		}
 
-		repo.EXPECT().AddLoanInvestments(ctx, investment).Return(nil)
+		loan := entity.Loan{
+			ID:              investment.LoanID,
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&loan, nil)
+		repo.EXPECT().AddLoanInvestments(ctx, investment, gomock.Nil()).Return(false, nil)
+
+		err := svc.InvestLoan(ctx, loanInvestReq)
+		assert.Nil(t, err)
+	})
+
+	t.Run("invest loan success, the closing pledge issues the agreement letter", func(t *testing.T) {
+
+		loanInvestReq := entity.LoanInvestRequest{
+			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+			Amount:     entity.NewMoney(500000, "IDR"),
+		}
+
+		investment := entity.LoanInvestment{
+			InvestorID: loanInvestReq.InvestorID,
+			Amount:     loanInvestReq.Amount,
+			LoanID:     uuid.MustParse(loanInvestReq.LoanID),
+		}
+
+		loan := entity.Loan{
+			ID:              investment.LoanID,
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			RemainingAmount: entity.NewMoney(500000, "IDR"),
+		}
+		invested := loan
+		invested.Status = "invested"
+		invested.RemainingAmount = entity.NewMoney(0, "IDR")
+
+		gomock.InOrder(
+			repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&loan, nil),
+			repo.EXPECT().AddLoanInvestments(ctx, investment, gomock.Nil()).Return(true, nil),
+			repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&invested, nil),
+		)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: investment.LoanID}).Return([]entity.InvestmentDetail{}, nil)
+		store.EXPECT().Put(ctx, gomock.Any(), "application/pdf", gomock.Any()).Return(nil)
+		//the letter completing the funding is issued by the system, not by the investor
+		repo.EXPECT().SaveAgreement(ctx, gomock.Any(), entity.SystemActor).Return(nil)
+
+		err := svc.InvestLoan(ctx, loanInvestReq)
+		assert.Nil(t, err)
+	})
+
+	t.Run("invest loan success, a pledge completing the loan after a concurrent one issues the letter", func(t *testing.T) {
+
+		loanInvestReq := entity.LoanInvestRequest{
+			InvestorID: uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+			LoanID:     "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+			Amount:     entity.NewMoney(400000, "IDR"),
+		}
+
+		investment := entity.LoanInvestment{
+			InvestorID: loanInvestReq.InvestorID,
+			Amount:     loanInvestReq.Amount,
+			LoanID:     uuid.MustParse(loanInvestReq.LoanID),
+		}
+
+		//read before a concurrent pledge of 600000 took the rest of the loan
+		loan := entity.Loan{
+			ID:              investment.LoanID,
+			Status:          "approved",
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			RemainingAmount: entity.NewMoney(1000000, "IDR"),
+		}
+		invested := loan
+		invested.Status = "invested"
+		invested.RemainingAmount = entity.NewMoney(0, "IDR")
+
+		gomock.InOrder(
+			repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&loan, nil),
+			repo.EXPECT().AddLoanInvestments(ctx, investment, gomock.Nil()).Return(true, nil),
+			repo.EXPECT().GetLoanByID(ctx, investment.LoanID).Return(&invested, nil),
+		)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: investment.LoanID}).Return([]entity.InvestmentDetail{}, nil)
+		store.EXPECT().Put(ctx, gomock.Any(), "application/pdf", gomock.Any()).Return(nil)
+		repo.EXPECT().SaveAgreement(ctx, gomock.Any(), entity.SystemActor).Return(nil)
 
 		err := svc.InvestLoan(ctx, loanInvestReq)
 		assert.Nil(t, err)
 	})
 }
 
+func Test_WithdrawInvestment(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+
+	loanWithdrawReq := entity.LoanWithdrawRequest{
+		LoanID:       "36b84065-1de5-47df-b1a6-311ff28dfe5b",
+		InvestmentID: "0b6c1b0e-55f4-4c4a-8d1b-3b1f1f0a9f21",
+		InvestorID:   uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886"),
+	}
+	loanID := uuid.MustParse(loanWithdrawReq.LoanID)
+
+	t.Run("withdraw investment failed, loan is already invested", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&entity.Loan{ID: loanID, Status: "invested"}, nil)
+
+		_, err := svc.WithdrawInvestment(ctx, loanWithdrawReq)
+		assert.True(t, errors.Is(err, apperror.ErrInvalidTransition))
+	})
+
+	t.Run("withdraw investment failed, investment not found", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&entity.Loan{ID: loanID, Status: "approved"}, nil)
+		repo.EXPECT().WithdrawInvestment(ctx, gomock.Any()).Return(apperror.ErrInvestmentNotFound)
+
+		_, err := svc.WithdrawInvestment(ctx, loanWithdrawReq)
+		assert.True(t, errors.Is(err, apperror.ErrInvestmentNotFound))
+	})
+
+	t.Run("withdraw investment success", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&entity.Loan{ID: loanID, Status: "approved"}, nil)
+		repo.EXPECT().WithdrawInvestment(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, withdrawal *entity.InvestmentWithdrawal) error {
+			assert.Equal(t, loanID, withdrawal.LoanID)
+			assert.Equal(t, uuid.MustParse(loanWithdrawReq.InvestmentID), withdrawal.InvestmentID)
+			assert.Equal(t, loanWithdrawReq.InvestorID, withdrawal.InvestorID)
+
+			withdrawal.Amount = entity.NewMoney(500000, "IDR")
+			withdrawal.RemainingAmount = entity.NewMoney(800000, "IDR")
+			return nil
+		})
+
+		withdrawal, err := svc.WithdrawInvestment(ctx, loanWithdrawReq)
+		assert.Nil(t, err)
+		assert.Equal(t, entity.NewMoney(500000, "IDR"), withdrawal.Amount)
+		assert.Equal(t, entity.NewMoney(800000, "IDR"), withdrawal.RemainingAmount)
+	})
+}
+
 func Test_DisburseLoan(t *testing.T) {
 	t.Parallel()
 
 	svc, repo := setupLoanService(t)
 	ctx := context.Background()
 
+	t.Run("disburse loan failed, loan ID is not a UUID", func(t *testing.T) {
+		err := svc.DisburseLoan(ctx, entity.LoanDisburseRequest{LoanID: "not-a-uuid", DisbursementDate: "2025-05-25"})
+		assert.True(t, errors.Is(err, apperror.ErrInvalidLoanID))
+	})
+
 	t.Run("disburse loan failed, error getting loan detail", func(t *testing.T) {
 		loanDisburseReq := entity.LoanDisburseRequest{
 			AgreementLetterLink: "./uploads/agreement.pdf",
@@ -185,15 +497,17 @@ func Test_DisburseLoan(t *testing.T) {
 
 		loan := entity.Loan{
 			ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
 			Status:          "approved",
 		}
 
 		repo.EXPECT().GetLoanByID(ctx, uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704")).Return(&loan, nil)
 
 		err := svc.DisburseLoan(ctx, loanDisburseReq)
-		assert.Equal(t, err.Error(), "loan principal amount is not met yet")
+		var transitionErr *InvalidTransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, err.Error(), "loan cannot transition from approved to disbursed")
 	})
 
 	t.Run("disburse loan failed, error on db during disbursement", func(t *testing.T) {
@@ -206,13 +520,15 @@ func Test_DisburseLoan(t *testing.T) {
 
 		loan := entity.Loan{
 			ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     12,
+			RepaymentMethod: "flat",
 			Status:          "invested",
 		}
 
 		repo.EXPECT().GetLoanByID(ctx, uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704")).Return(&loan, nil)
-		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), uuid.MustParse("75ed6802-8f18-4c5e-95b6-e8bd35e8d940")).Return(errors.New("db query error when disbursement"))
+		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), gomock.Any(), uuid.MustParse("75ed6802-8f18-4c5e-95b6-e8bd35e8d940")).Return(errors.New("db query error when disbursement"))
 
 		err := svc.DisburseLoan(ctx, loanDisburseReq)
 		assert.Equal(t, err.Error(), "db query error when disbursement")
@@ -228,15 +544,363 @@ func Test_DisburseLoan(t *testing.T) {
 
 		loan := entity.Loan{
 			ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
-			PrincipalAmount: 1000000,
-			InterestRate:    10.0,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     12,
+			RepaymentMethod: "flat",
 			Status:          "invested",
 		}
 
 		repo.EXPECT().GetLoanByID(ctx, uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704")).Return(&loan, nil)
-		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), uuid.MustParse("75ed6802-8f18-4c5e-95b6-e8bd35e8d940")).Return(nil)
+		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), gomock.Len(12), uuid.MustParse("75ed6802-8f18-4c5e-95b6-e8bd35e8d940")).Return(nil)
+
+		err := svc.DisburseLoan(ctx, loanDisburseReq)
+		assert.Nil(t, err)
+	})
+
+	t.Run("disburse loan success, without upload the generated agreement letter is kept", func(t *testing.T) {
+		loanDisburseReq := entity.LoanDisburseRequest{
+			DisbursementDate: "2025-05-25",
+			LoanID:           "2badced4-3fa0-4a7e-8dcf-7c8031f0e704",
+			StaffID:          "75ed6802-8f18-4c5e-95b6-e8bd35e8d940",
+		}
+
+		loan := entity.Loan{
+			ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1000,
+			TenorMonths:     12,
+			RepaymentMethod: "flat",
+			AgreementLetter: "/v1/loans/2badced4-3fa0-4a7e-8dcf-7c8031f0e704/agreement",
+			Status:          "invested",
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().DisburseLoan(ctx, gomock.Any(), gomock.Len(12), uuid.MustParse("75ed6802-8f18-4c5e-95b6-e8bd35e8d940")).
+			DoAndReturn(func(_ context.Context, disbursed *entity.Loan, _ []entity.LoanInstalment, _ uuid.UUID) error {
+				assert.Equal(t, loan.AgreementLetter, disbursed.AgreementLetter)
+				return nil
+			})
 
 		err := svc.DisburseLoan(ctx, loanDisburseReq)
 		assert.Nil(t, err)
 	})
 }
+
+func Test_ListLoans(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+
+	t.Run("list loans failed, unknown status", func(t *testing.T) {
+		_, err := svc.ListLoans(ctx, entity.LoanListRequest{Statuses: []string{"approved,funded"}})
+		assert.True(t, errors.Is(err, ErrInvalidLoanFilter))
+	})
+
+	t.Run("list loans failed, invalid cursor", func(t *testing.T) {
+		_, err := svc.ListLoans(ctx, entity.LoanListRequest{Cursor: "not-a-cursor"})
+		assert.True(t, errors.Is(err, ErrInvalidCursor))
+	})
+
+	t.Run("list loans failed, error db", func(t *testing.T) {
+		repo.EXPECT().ListLoans(ctx, gomock.Any()).Return(nil, errors.New("db error"))
+
+		_, err := svc.ListLoans(ctx, entity.LoanListRequest{})
+		assert.Equal(t, err.Error(), "db error")
+	})
+
+	t.Run("list loans success, last page", func(t *testing.T) {
+		filter := entity.LoanFilter{
+			Statuses: []string{"approved", "invested"},
+			Limit:    defaultListLimit + 1,
+		}
+		loans := []entity.Loan{{ID: uuid.New(), Status: "approved"}}
+
+		repo.EXPECT().ListLoans(ctx, filter).Return(loans, nil)
+
+		page, err := svc.ListLoans(ctx, entity.LoanListRequest{Statuses: []string{"approved", "invested"}})
+		assert.Nil(t, err)
+		assert.Len(t, page.Loans, 1)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("list loans success, next cursor points at last returned row", func(t *testing.T) {
+		createdAt := time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)
+		loans := []entity.Loan{
+			{ID: uuid.MustParse("a98ba4bd-1e09-4134-b244-d89f9a86a44c"), CreatedAt: createdAt.Add(time.Minute)},
+			{ID: uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b"), CreatedAt: createdAt},
+			{ID: uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"), CreatedAt: createdAt.Add(-time.Minute)},
+		}
+
+		repo.EXPECT().ListLoans(ctx, entity.LoanFilter{Limit: 3}).Return(loans, nil)
+
+		page, err := svc.ListLoans(ctx, entity.LoanListRequest{Limit: 2})
+		assert.Nil(t, err)
+		assert.Len(t, page.Loans, 2)
+
+		//the next page continues after the second loan
+		next := entity.LoanFilter{
+			Limit:          3,
+			AfterCreatedAt: createdAt,
+			AfterID:        uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b"),
+			Ascending:      true,
+		}
+		repo.EXPECT().ListLoans(ctx, next).Return(loans[2:], nil)
+
+		page, err = svc.ListLoans(ctx, entity.LoanListRequest{Limit: 2, Cursor: page.NextCursor, Sort: "created_at"})
+		assert.Nil(t, err)
+		assert.Len(t, page.Loans, 1)
+	})
+}
+
+func Test_ListLoanInvestments(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+	loanID := uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b")
+
+	t.Run("list loan investments failed, loan not found", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(nil, apperror.ErrLoanNotFound)
+
+		_, err := svc.ListLoanInvestments(ctx, loanID)
+		assert.True(t, errors.Is(err, apperror.ErrLoanNotFound))
+	})
+
+	t.Run("list loan investments success", func(t *testing.T) {
+		investments := []entity.InvestmentDetail{
+			{
+				LoanInvestment:  entity.LoanInvestment{LoanID: loanID, Amount: entity.NewMoney(250000, "IDR")},
+				LoanStatus:      "approved",
+				PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+				InterestRate:    1000,
+				TenorMonths:     12,
+				RepaymentMethod: entity.RepaymentMethodFlat,
+			},
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&entity.Loan{ID: loanID}, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return(investments, nil)
+
+		res, err := svc.ListLoanInvestments(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Equal(t, entity.Rate(2500), res[0].ShareOfPrincipal)
+		assert.Equal(t, entity.NewMoney(250000, "IDR"), res[0].PrincipalShare)
+		assert.Equal(t, entity.NewMoney(25000, "IDR"), res[0].InterestShare)
+		assert.Equal(t, entity.NewMoney(275000, "IDR"), res[0].ProjectedReturn)
+	})
+}
+
+func Test_ListInvestorInvestments(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+	investorID := uuid.MustParse("e217fd14-0de2-4a11-8989-d8d51e2b9886")
+
+	t.Run("list investor investments failed, error db", func(t *testing.T) {
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{InvestorID: investorID, CoInvestors: true}).Return(nil, errors.New("db error"))
+
+		_, err := svc.ListInvestorInvestments(ctx, investorID)
+		assert.Equal(t, err.Error(), "db error")
+	})
+
+	t.Run("list investor investments success", func(t *testing.T) {
+		loanID := uuid.MustParse("36b84065-1de5-47df-b1a6-311ff28dfe5b")
+		investments := []entity.InvestmentDetail{
+			{
+				LoanInvestment:  entity.LoanInvestment{LoanID: loanID, InvestorID: uuid.New(), Amount: entity.NewMoney(1500000, "IDR")},
+				LoanStatus:      "invested",
+				PrincipalAmount: entity.NewMoney(2000000, "IDR"),
+				InterestRate:    1200,
+				TenorMonths:     12,
+				RepaymentMethod: entity.RepaymentMethodFlat,
+			},
+			{
+				LoanInvestment:  entity.LoanInvestment{LoanID: loanID, InvestorID: investorID, Amount: entity.NewMoney(500000, "IDR")},
+				LoanStatus:      "invested",
+				PrincipalAmount: entity.NewMoney(2000000, "IDR"),
+				InterestRate:    1200,
+				TenorMonths:     12,
+				RepaymentMethod: entity.RepaymentMethodFlat,
+			},
+		}
+
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{InvestorID: investorID, CoInvestors: true}).Return(investments, nil)
+
+		res, err := svc.ListInvestorInvestments(ctx, investorID)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, investorID, res[0].InvestorID)
+		assert.Equal(t, entity.Rate(2500), res[0].ShareOfPrincipal)
+		assert.Equal(t, entity.NewMoney(60000, "IDR"), res[0].InterestShare)
+		assert.Equal(t, entity.NewMoney(560000, "IDR"), res[0].ProjectedReturn)
+	})
+}
+
+func Test_GetLoanByID(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+	loanID := uuid.MustParse("a98ba4bd-1e09-4134-b244-d89f9a86a44c")
+
+	t.Run("get loan failed, loan not found", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(nil, apperror.ErrLoanNotFound)
+
+		_, err := svc.GetLoanByID(ctx, loanID)
+		assert.True(t, errors.Is(err, apperror.ErrLoanNotFound))
+	})
+
+	t.Run("get loan success, returns split per investor", func(t *testing.T) {
+		loan := entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1050,
+			TenorMonths:     12,
+			RepaymentMethod: entity.RepaymentMethodFlat,
+			Status:          "invested",
+		}
+		investments := []entity.InvestmentDetail{
+			{LoanInvestment: entity.LoanInvestment{LoanID: loanID, InvestorID: uuid.New(), Amount: entity.NewMoney(333333, "IDR")}},
+			{LoanInvestment: entity.LoanInvestment{LoanID: loanID, InvestorID: uuid.New(), Amount: entity.NewMoney(333333, "IDR")}},
+			{LoanInvestment: entity.LoanInvestment{LoanID: loanID, InvestorID: uuid.New(), Amount: entity.NewMoney(333334, "IDR")}},
+		}
+
+		repo.EXPECT().GetLoanByID(ctx, loanID).Return(&loan, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID}).Return(investments, nil)
+
+		res, err := svc.GetLoanByID(ctx, loanID)
+		assert.Nil(t, err)
+		assert.Equal(t, entity.NewMoney(105000, "IDR"), res.Returns)
+		assert.Len(t, res.InvestorReturns, 3)
+
+		var interest int64
+		for _, ret := range res.InvestorReturns {
+			interest += ret.InterestShare.Amount
+		}
+		assert.Equal(t, res.Returns.Amount, interest)
+	})
+}
+
+func Test_GetLoanSchedule(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+
+	loan := entity.Loan{
+		ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1200,
+		TenorMonths:     3,
+		RepaymentMethod: "flat",
+		Status:          "approved",
+	}
+
+	t.Run("get schedule failed, loan is not disbursed", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().ListInstalments(ctx, loan.ID).Return([]entity.LoanInstalment{}, nil)
+
+		_, err := svc.GetLoanSchedule(ctx, loan.ID)
+		assert.True(t, errors.Is(err, apperror.ErrScheduleNotAvailable))
+	})
+
+	t.Run("get schedule success", func(t *testing.T) {
+		instalments := repaymentSchedule(&loan, time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC), 1)
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().ListInstalments(ctx, loan.ID).Return(instalments, nil)
+
+		res, err := svc.GetLoanSchedule(ctx, loan.ID)
+		assert.Nil(t, err)
+		assert.Len(t, res.Instalments, 3)
+		assert.Equal(t, entity.NewMoney(1000000, "IDR"), res.TotalPrincipal)
+		assert.Equal(t, entity.NewMoney(30000, "IDR"), res.TotalInterest)
+		assert.Equal(t, entity.NewMoney(1030000, "IDR"), res.TotalAmount)
+	})
+}
+
+func Test_RepayLoan(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupLoanService(t)
+	ctx := context.Background()
+
+	loan := entity.Loan{
+		ID:              uuid.MustParse("2badced4-3fa0-4a7e-8dcf-7c8031f0e704"),
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1200,
+		TenorMonths:     2,
+		RepaymentMethod: "flat",
+		Status:          "disbursed",
+	}
+	instalments := repaymentSchedule(&loan, time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC), 1)
+
+	investments := []entity.InvestmentDetail{
+		{LoanInvestment: entity.LoanInvestment{ID: uuid.New(), InvestorID: uuid.New(), Amount: entity.NewMoney(700000, "IDR")}},
+		{LoanInvestment: entity.LoanInvestment{ID: uuid.New(), InvestorID: uuid.New(), Amount: entity.NewMoney(300000, "IDR")}},
+	}
+
+	req := entity.LoanRepaymentRequest{
+		LoanID:  loan.ID.String(),
+		Amount:  entity.NewMoney(510000, "IDR"),
+		StaffID: "75ed6802-8f18-4c5e-95b6-e8bd35e8d940",
+	}
+
+	t.Run("repay loan failed, loan is not disbursed", func(t *testing.T) {
+		invested := loan
+		invested.Status = "invested"
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&invested, nil)
+
+		_, err := svc.RepayLoan(ctx, req)
+		var transitionErr *InvalidTransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+	})
+
+	t.Run("repay loan failed, more than outstanding", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().ListInstalments(ctx, loan.ID).Return(instalments, nil)
+
+		overpaid := req
+		overpaid.Amount = entity.NewMoney(1020001, "IDR")
+		_, err := svc.RepayLoan(ctx, overpaid)
+		assert.True(t, errors.Is(err, apperror.ErrRepaymentExceedsOutstanding))
+	})
+
+	t.Run("repay loan success, first repayment moves the loan to repaying", func(t *testing.T) {
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&loan, nil)
+		repo.EXPECT().ListInstalments(ctx, loan.ID).Return(instalments, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loan.ID}).Return(investments, nil)
+		repo.EXPECT().RecordRepayment(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, record *entity.RepaymentRecord) error {
+			assert.Equal(t, "repaying", record.StatusChange.To)
+			assert.Len(t, record.After, 1)
+			return nil
+		})
+
+		res, err := svc.RepayLoan(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "repaying", res.LoanStatus)
+		assert.Equal(t, entity.NewMoney(10000, "IDR"), res.Allocations[0].Interest)
+		assert.Equal(t, entity.NewMoney(500000, "IDR"), res.Allocations[0].Principal)
+		assert.Len(t, res.Payouts, 2)
+		assert.Equal(t, entity.NewMoney(357000, "IDR"), res.Payouts[0].Amount)
+		assert.Equal(t, entity.NewMoney(153000, "IDR"), res.Payouts[1].Amount)
+	})
+
+	t.Run("repay loan success, last repayment closes the loan", func(t *testing.T) {
+		repaying := loan
+		repaying.Status = "repaying"
+		_, paid, _ := allocateRepayment(instalments, 510000)
+
+		repo.EXPECT().GetLoanByID(ctx, loan.ID).Return(&repaying, nil)
+		repo.EXPECT().ListInstalments(ctx, loan.ID).Return([]entity.LoanInstalment{paid[0], instalments[1]}, nil)
+		repo.EXPECT().ListInvestments(ctx, entity.InvestmentFilter{LoanID: loan.ID}).Return(investments, nil)
+		repo.EXPECT().RecordRepayment(ctx, gomock.Any()).Return(nil)
+
+		res, err := svc.RepayLoan(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "closed", res.LoanStatus)
+	})
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+// loanTransitions lists, for every status, the statuses a loan is allowed to move to next.
+// Statuses without an entry (rejected, cancelled, expired, closed) are terminal.
+var loanTransitions = map[string][]string{
+	entity.LoanStatusProposed:  {entity.LoanStatusApproved, entity.LoanStatusRejected, entity.LoanStatusCancelled},
+	entity.LoanStatusApproved:  {entity.LoanStatusInvested, entity.LoanStatusCancelled, entity.LoanStatusExpired},
+	entity.LoanStatusInvested:  {entity.LoanStatusDisbursed},
+	entity.LoanStatusDisbursed: {entity.LoanStatusRepaying, entity.LoanStatusClosed},
+	entity.LoanStatusRepaying:  {entity.LoanStatusClosed},
+}
+
+// staffStatuses are the statuses staff can set directly through UpdateLoan,
+// the other ones are reached through investment, disbursement, repayment or expiry
+var staffStatuses = map[string]bool{
+	entity.LoanStatusApproved:  true,
+	entity.LoanStatusRejected:  true,
+	entity.LoanStatusCancelled: true,
+}
+
+// InvalidTransitionError details a refused transition, it is returned wrapped in apperror.ErrInvalidTransition
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("loan cannot transition from %s to %s", e.From, e.To)
+}
+
+// checkTransition returns apperror.ErrInvalidTransition (wrapping *InvalidTransitionError) if a loan in status `from` is not allowed to move to status `to`
+func checkTransition(from, to string) error {
+	for _, next := range loanTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return invalidTransition(from, to)
+}
+
+func invalidTransition(from, to string) error {
+	err := &InvalidTransitionError{From: from, To: to}
+	return apperror.ErrInvalidTransition.Withf("%s", err.Error()).Wrap(err)
+}
+
+// checkStaffTransition is checkTransition restricted to the statuses staff may set by hand
+func checkStaffTransition(from, to string) error {
+	if !staffStatuses[to] {
+		return invalidTransition(from, to)
+	}
+	return checkTransition(from, to)
+}
+
+// checkInvestable tells whether a loan still accepts investments, that is whether it may become invested
+func checkInvestable(status string) error {
+	return checkTransition(status, entity.LoanStatusInvested)
+}
+
+// checkRepayable tells whether a loan accepts repayments, that is once it has been disbursed and until it is closed
+func checkRepayable(status string) error {
+	if status == entity.LoanStatusRepaying {
+		return nil
+	}
+	return checkTransition(status, entity.LoanStatusRepaying)
+}
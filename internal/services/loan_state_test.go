@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from  string
+		to    string
+		valid bool
+	}{
+		{"proposed", "approved", true},
+		{"proposed", "rejected", true},
+		{"proposed", "cancelled", true},
+		{"proposed", "invested", false},
+		{"proposed", "disbursed", false},
+		{"approved", "invested", true},
+		{"approved", "cancelled", true},
+		{"approved", "expired", true},
+		{"approved", "proposed", false},
+		{"invested", "disbursed", true},
+		{"invested", "approved", false},
+		{"disbursed", "proposed", false},
+		{"disbursed", "repaying", true},
+		{"disbursed", "closed", true},
+		{"repaying", "closed", true},
+		{"repaying", "disbursed", false},
+		{"closed", "repaying", false},
+		{"rejected", "approved", false},
+		{"cancelled", "approved", false},
+		{"expired", "approved", false},
+		{"proposed", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		err := checkTransition(tt.from, tt.to)
+		assert.Equal(t, tt.valid, err == nil, "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func Test_CheckStaffTransition(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, checkStaffTransition("proposed", "approved"))
+	assert.Nil(t, checkStaffTransition("approved", "cancelled"))
+
+	// reachable in the state machine, but only through investment / disbursement
+	assert.NotNil(t, checkStaffTransition("approved", "invested"))
+	assert.NotNil(t, checkStaffTransition("invested", "disbursed"))
+}
+
+func Test_CheckRepayable(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, checkRepayable("disbursed"))
+	assert.Nil(t, checkRepayable("repaying"))
+	assert.NotNil(t, checkRepayable("invested"))
+	assert.NotNil(t, checkRepayable("closed"))
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: document.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDocumentStore is a mock of DocumentStore interface.
+type MockDocumentStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentStoreMockRecorder
+}
+
+// MockDocumentStoreMockRecorder is the mock recorder for MockDocumentStore.
+type MockDocumentStoreMockRecorder struct {
+	mock *MockDocumentStore
+}
+
+// NewMockDocumentStore creates a new mock instance.
+func NewMockDocumentStore(ctrl *gomock.Controller) *MockDocumentStore {
+	mock := &MockDocumentStore{ctrl: ctrl}
+	mock.recorder = &MockDocumentStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocumentStore) EXPECT() *MockDocumentStoreMockRecorder {
+	return m.recorder
+}
+
+// Put mocks base method.
+func (m *MockDocumentStore) Put(ctx context.Context, key, contentType string, content []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, contentType, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockDocumentStoreMockRecorder) Put(ctx, key, contentType, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockDocumentStore)(nil).Put), ctx, key, contentType, content)
+}
+
+// SignedURL mocks base method.
+func (m *MockDocumentStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedURL", ctx, key, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedURL indicates an expected call of SignedURL.
+func (mr *MockDocumentStoreMockRecorder) SignedURL(ctx, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedURL", reflect.TypeOf((*MockDocumentStore)(nil).SignedURL), ctx, key, ttl)
+}
@@ -0,0 +1,135 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ledger_service.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entity "github.com/ferdikurniawan/loan-service/internal/entity"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockLedgerService is a mock of LedgerService interface.
+type MockLedgerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerServiceMockRecorder
+}
+
+// MockLedgerServiceMockRecorder is the mock recorder for MockLedgerService.
+type MockLedgerServiceMockRecorder struct {
+	mock *MockLedgerService
+}
+
+// NewMockLedgerService creates a new mock instance.
+func NewMockLedgerService(ctrl *gomock.Controller) *MockLedgerService {
+	mock := &MockLedgerService{ctrl: ctrl}
+	mock.recorder = &MockLedgerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerService) EXPECT() *MockLedgerServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckIntegrity mocks base method.
+func (m *MockLedgerService) CheckIntegrity(ctx context.Context) (*entity.LedgerIntegrity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckIntegrity", ctx)
+	ret0, _ := ret[0].(*entity.LedgerIntegrity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckIntegrity indicates an expected call of CheckIntegrity.
+func (mr *MockLedgerServiceMockRecorder) CheckIntegrity(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIntegrity", reflect.TypeOf((*MockLedgerService)(nil).CheckIntegrity), ctx)
+}
+
+// ListBalances mocks base method.
+func (m *MockLedgerService) ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBalances", ctx, filter)
+	ret0, _ := ret[0].([]entity.AccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBalances indicates an expected call of ListBalances.
+func (mr *MockLedgerServiceMockRecorder) ListBalances(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBalances", reflect.TypeOf((*MockLedgerService)(nil).ListBalances), ctx, filter)
+}
+
+// MockLedgerRepo is a mock of LedgerRepo interface.
+type MockLedgerRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerRepoMockRecorder
+}
+
+// MockLedgerRepoMockRecorder is the mock recorder for MockLedgerRepo.
+type MockLedgerRepoMockRecorder struct {
+	mock *MockLedgerRepo
+}
+
+// NewMockLedgerRepo creates a new mock instance.
+func NewMockLedgerRepo(ctrl *gomock.Controller) *MockLedgerRepo {
+	mock := &MockLedgerRepo{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerRepo) EXPECT() *MockLedgerRepoMockRecorder {
+	return m.recorder
+}
+
+// LedgerTotals mocks base method.
+func (m *MockLedgerRepo) LedgerTotals(ctx context.Context) ([]entity.LedgerTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LedgerTotals", ctx)
+	ret0, _ := ret[0].([]entity.LedgerTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LedgerTotals indicates an expected call of LedgerTotals.
+func (mr *MockLedgerRepoMockRecorder) LedgerTotals(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LedgerTotals", reflect.TypeOf((*MockLedgerRepo)(nil).LedgerTotals), ctx)
+}
+
+// ListBalances mocks base method.
+func (m *MockLedgerRepo) ListBalances(ctx context.Context, filter entity.AccountFilter) ([]entity.AccountBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBalances", ctx, filter)
+	ret0, _ := ret[0].([]entity.AccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBalances indicates an expected call of ListBalances.
+func (mr *MockLedgerRepoMockRecorder) ListBalances(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBalances", reflect.TypeOf((*MockLedgerRepo)(nil).ListBalances), ctx, filter)
+}
+
+// ListUnbalancedTransactions mocks base method.
+func (m *MockLedgerRepo) ListUnbalancedTransactions(ctx context.Context) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnbalancedTransactions", ctx)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnbalancedTransactions indicates an expected call of ListUnbalancedTransactions.
+func (mr *MockLedgerRepoMockRecorder) ListUnbalancedTransactions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnbalancedTransactions", reflect.TypeOf((*MockLedgerRepo)(nil).ListUnbalancedTransactions), ctx)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: internal/services/loan_service.go
+// Source: loan_service.go
 
 // Package mock is a generated GoMock package.
 package mock
@@ -65,6 +65,36 @@ func (mr *MockLoanServiceMockRecorder) DisburseLoan(ctx, loanDisburseRequest int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisburseLoan", reflect.TypeOf((*MockLoanService)(nil).DisburseLoan), ctx, loanDisburseRequest)
 }
 
+// ExpireOverdueLoans mocks base method.
+func (m *MockLoanService) ExpireOverdueLoans(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireOverdueLoans", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireOverdueLoans indicates an expected call of ExpireOverdueLoans.
+func (mr *MockLoanServiceMockRecorder) ExpireOverdueLoans(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireOverdueLoans", reflect.TypeOf((*MockLoanService)(nil).ExpireOverdueLoans), ctx)
+}
+
+// GetAgreement mocks base method.
+func (m *MockLoanService) GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgreement", ctx, loanID)
+	ret0, _ := ret[0].(*entity.LoanAgreement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgreement indicates an expected call of GetAgreement.
+func (mr *MockLoanServiceMockRecorder) GetAgreement(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgreement", reflect.TypeOf((*MockLoanService)(nil).GetAgreement), ctx, loanID)
+}
+
 // GetLoanByID mocks base method.
 func (m *MockLoanService) GetLoanByID(ctx context.Context, loanID uuid.UUID) (*entity.Loan, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +110,36 @@ func (mr *MockLoanServiceMockRecorder) GetLoanByID(ctx, loanID interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoanByID", reflect.TypeOf((*MockLoanService)(nil).GetLoanByID), ctx, loanID)
 }
 
+// GetLoanHistory mocks base method.
+func (m *MockLoanService) GetLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoanHistory", ctx, loanID)
+	ret0, _ := ret[0].([]entity.LoanHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoanHistory indicates an expected call of GetLoanHistory.
+func (mr *MockLoanServiceMockRecorder) GetLoanHistory(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoanHistory", reflect.TypeOf((*MockLoanService)(nil).GetLoanHistory), ctx, loanID)
+}
+
+// GetLoanSchedule mocks base method.
+func (m *MockLoanService) GetLoanSchedule(ctx context.Context, loanID uuid.UUID) (*entity.LoanSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoanSchedule", ctx, loanID)
+	ret0, _ := ret[0].(*entity.LoanSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoanSchedule indicates an expected call of GetLoanSchedule.
+func (mr *MockLoanServiceMockRecorder) GetLoanSchedule(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoanSchedule", reflect.TypeOf((*MockLoanService)(nil).GetLoanSchedule), ctx, loanID)
+}
+
 // InvestLoan mocks base method.
 func (m *MockLoanService) InvestLoan(ctx context.Context, loanInvestRequest entity.LoanInvestRequest) error {
 	m.ctrl.T.Helper()
@@ -94,6 +154,96 @@ func (mr *MockLoanServiceMockRecorder) InvestLoan(ctx, loanInvestRequest interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvestLoan", reflect.TypeOf((*MockLoanService)(nil).InvestLoan), ctx, loanInvestRequest)
 }
 
+// IssueAgreement mocks base method.
+func (m *MockLoanService) IssueAgreement(ctx context.Context, loanID uuid.UUID, actor entity.Actor) (*entity.LoanAgreement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAgreement", ctx, loanID, actor)
+	ret0, _ := ret[0].(*entity.LoanAgreement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAgreement indicates an expected call of IssueAgreement.
+func (mr *MockLoanServiceMockRecorder) IssueAgreement(ctx, loanID, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAgreement", reflect.TypeOf((*MockLoanService)(nil).IssueAgreement), ctx, loanID, actor)
+}
+
+// ListInvestorInvestments mocks base method.
+func (m *MockLoanService) ListInvestorInvestments(ctx context.Context, investorID uuid.UUID) ([]entity.InvestmentDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvestorInvestments", ctx, investorID)
+	ret0, _ := ret[0].([]entity.InvestmentDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvestorInvestments indicates an expected call of ListInvestorInvestments.
+func (mr *MockLoanServiceMockRecorder) ListInvestorInvestments(ctx, investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvestorInvestments", reflect.TypeOf((*MockLoanService)(nil).ListInvestorInvestments), ctx, investorID)
+}
+
+// ListInvestorPayouts mocks base method.
+func (m *MockLoanService) ListInvestorPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvestorPayouts", ctx, filter)
+	ret0, _ := ret[0].([]entity.InvestorPayout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvestorPayouts indicates an expected call of ListInvestorPayouts.
+func (mr *MockLoanServiceMockRecorder) ListInvestorPayouts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvestorPayouts", reflect.TypeOf((*MockLoanService)(nil).ListInvestorPayouts), ctx, filter)
+}
+
+// ListLoanInvestments mocks base method.
+func (m *MockLoanService) ListLoanInvestments(ctx context.Context, loanID uuid.UUID) ([]entity.InvestmentDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoanInvestments", ctx, loanID)
+	ret0, _ := ret[0].([]entity.InvestmentDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoanInvestments indicates an expected call of ListLoanInvestments.
+func (mr *MockLoanServiceMockRecorder) ListLoanInvestments(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoanInvestments", reflect.TypeOf((*MockLoanService)(nil).ListLoanInvestments), ctx, loanID)
+}
+
+// ListLoans mocks base method.
+func (m *MockLoanService) ListLoans(ctx context.Context, loanListRequest entity.LoanListRequest) (*entity.LoanPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoans", ctx, loanListRequest)
+	ret0, _ := ret[0].(*entity.LoanPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoans indicates an expected call of ListLoans.
+func (mr *MockLoanServiceMockRecorder) ListLoans(ctx, loanListRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoans", reflect.TypeOf((*MockLoanService)(nil).ListLoans), ctx, loanListRequest)
+}
+
+// RepayLoan mocks base method.
+func (m *MockLoanService) RepayLoan(ctx context.Context, loanRepaymentRequest entity.LoanRepaymentRequest) (*entity.Repayment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepayLoan", ctx, loanRepaymentRequest)
+	ret0, _ := ret[0].(*entity.Repayment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepayLoan indicates an expected call of RepayLoan.
+func (mr *MockLoanServiceMockRecorder) RepayLoan(ctx, loanRepaymentRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepayLoan", reflect.TypeOf((*MockLoanService)(nil).RepayLoan), ctx, loanRepaymentRequest)
+}
+
 // UpdateLoan mocks base method.
 func (m *MockLoanService) UpdateLoan(ctx context.Context, loanStatusRequest entity.LoanUpdateRequest) error {
 	m.ctrl.T.Helper()
@@ -108,6 +258,21 @@ func (mr *MockLoanServiceMockRecorder) UpdateLoan(ctx, loanStatusRequest interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoan", reflect.TypeOf((*MockLoanService)(nil).UpdateLoan), ctx, loanStatusRequest)
 }
 
+// WithdrawInvestment mocks base method.
+func (m *MockLoanService) WithdrawInvestment(ctx context.Context, loanWithdrawRequest entity.LoanWithdrawRequest) (*entity.InvestmentWithdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawInvestment", ctx, loanWithdrawRequest)
+	ret0, _ := ret[0].(*entity.InvestmentWithdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawInvestment indicates an expected call of WithdrawInvestment.
+func (mr *MockLoanServiceMockRecorder) WithdrawInvestment(ctx, loanWithdrawRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawInvestment", reflect.TypeOf((*MockLoanService)(nil).WithdrawInvestment), ctx, loanWithdrawRequest)
+}
+
 // MockLoanRepo is a mock of LoanRepo interface.
 type MockLoanRepo struct {
 	ctrl     *gomock.Controller
@@ -132,31 +297,47 @@ func (m *MockLoanRepo) EXPECT() *MockLoanRepoMockRecorder {
 }
 
 // AddLoanInvestments mocks base method.
-func (m *MockLoanRepo) AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment) error {
+func (m *MockLoanRepo) AddLoanInvestments(ctx context.Context, investment entity.LoanInvestment, checkInvestor func(entity.InvestorTotals) error) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddLoanInvestments", ctx, investment)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "AddLoanInvestments", ctx, investment, checkInvestor)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // AddLoanInvestments indicates an expected call of AddLoanInvestments.
-func (mr *MockLoanRepoMockRecorder) AddLoanInvestments(ctx, investment interface{}) *gomock.Call {
+func (mr *MockLoanRepoMockRecorder) AddLoanInvestments(ctx, investment, checkInvestor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoanInvestments", reflect.TypeOf((*MockLoanRepo)(nil).AddLoanInvestments), ctx, investment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoanInvestments", reflect.TypeOf((*MockLoanRepo)(nil).AddLoanInvestments), ctx, investment, checkInvestor)
 }
 
 // DisburseLoan mocks base method.
-func (m *MockLoanRepo) DisburseLoan(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error {
+func (m *MockLoanRepo) DisburseLoan(ctx context.Context, loan *entity.Loan, schedule []entity.LoanInstalment, staffID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DisburseLoan", ctx, loan, staffID)
+	ret := m.ctrl.Call(m, "DisburseLoan", ctx, loan, schedule, staffID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DisburseLoan indicates an expected call of DisburseLoan.
-func (mr *MockLoanRepoMockRecorder) DisburseLoan(ctx, loan, staffID interface{}) *gomock.Call {
+func (mr *MockLoanRepoMockRecorder) DisburseLoan(ctx, loan, schedule, staffID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisburseLoan", reflect.TypeOf((*MockLoanRepo)(nil).DisburseLoan), ctx, loan, staffID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisburseLoan", reflect.TypeOf((*MockLoanRepo)(nil).DisburseLoan), ctx, loan, schedule, staffID)
+}
+
+// GetAgreement mocks base method.
+func (m *MockLoanRepo) GetAgreement(ctx context.Context, loanID uuid.UUID) (*entity.LoanAgreement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgreement", ctx, loanID)
+	ret0, _ := ret[0].(*entity.LoanAgreement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgreement indicates an expected call of GetAgreement.
+func (mr *MockLoanRepoMockRecorder) GetAgreement(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgreement", reflect.TypeOf((*MockLoanRepo)(nil).GetAgreement), ctx, loanID)
 }
 
 // GetLoanByID mocks base method.
@@ -189,16 +370,133 @@ func (mr *MockLoanRepoMockRecorder) InsertLoan(ctx, loan interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLoan", reflect.TypeOf((*MockLoanRepo)(nil).InsertLoan), ctx, loan)
 }
 
+// ListInstalments mocks base method.
+func (m *MockLoanRepo) ListInstalments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanInstalment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInstalments", ctx, loanID)
+	ret0, _ := ret[0].([]entity.LoanInstalment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInstalments indicates an expected call of ListInstalments.
+func (mr *MockLoanRepoMockRecorder) ListInstalments(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstalments", reflect.TypeOf((*MockLoanRepo)(nil).ListInstalments), ctx, loanID)
+}
+
+// ListInvestments mocks base method.
+func (m *MockLoanRepo) ListInvestments(ctx context.Context, filter entity.InvestmentFilter) ([]entity.InvestmentDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvestments", ctx, filter)
+	ret0, _ := ret[0].([]entity.InvestmentDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvestments indicates an expected call of ListInvestments.
+func (mr *MockLoanRepoMockRecorder) ListInvestments(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvestments", reflect.TypeOf((*MockLoanRepo)(nil).ListInvestments), ctx, filter)
+}
+
+// ListLoanHistory mocks base method.
+func (m *MockLoanRepo) ListLoanHistory(ctx context.Context, loanID uuid.UUID) ([]entity.LoanHistoryRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoanHistory", ctx, loanID)
+	ret0, _ := ret[0].([]entity.LoanHistoryRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoanHistory indicates an expected call of ListLoanHistory.
+func (mr *MockLoanRepoMockRecorder) ListLoanHistory(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoanHistory", reflect.TypeOf((*MockLoanRepo)(nil).ListLoanHistory), ctx, loanID)
+}
+
+// ListLoans mocks base method.
+func (m *MockLoanRepo) ListLoans(ctx context.Context, filter entity.LoanFilter) ([]entity.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoans", ctx, filter)
+	ret0, _ := ret[0].([]entity.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoans indicates an expected call of ListLoans.
+func (mr *MockLoanRepoMockRecorder) ListLoans(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoans", reflect.TypeOf((*MockLoanRepo)(nil).ListLoans), ctx, filter)
+}
+
+// ListPayouts mocks base method.
+func (m *MockLoanRepo) ListPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPayouts", ctx, filter)
+	ret0, _ := ret[0].([]entity.InvestorPayout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPayouts indicates an expected call of ListPayouts.
+func (mr *MockLoanRepoMockRecorder) ListPayouts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayouts", reflect.TypeOf((*MockLoanRepo)(nil).ListPayouts), ctx, filter)
+}
+
+// RecordRepayment mocks base method.
+func (m *MockLoanRepo) RecordRepayment(ctx context.Context, record *entity.RepaymentRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRepayment", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRepayment indicates an expected call of RecordRepayment.
+func (mr *MockLoanRepoMockRecorder) RecordRepayment(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRepayment", reflect.TypeOf((*MockLoanRepo)(nil).RecordRepayment), ctx, record)
+}
+
+// SaveAgreement mocks base method.
+func (m *MockLoanRepo) SaveAgreement(ctx context.Context, agreement *entity.LoanAgreement, actor entity.Actor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAgreement", ctx, agreement, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAgreement indicates an expected call of SaveAgreement.
+func (mr *MockLoanRepoMockRecorder) SaveAgreement(ctx, agreement, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAgreement", reflect.TypeOf((*MockLoanRepo)(nil).SaveAgreement), ctx, agreement, actor)
+}
+
 // UpdateLoanStatus mocks base method.
-func (m *MockLoanRepo) UpdateLoanStatus(ctx context.Context, loan *entity.Loan, staffID uuid.UUID) error {
+func (m *MockLoanRepo) UpdateLoanStatus(ctx context.Context, change entity.LoanStatusChange) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateLoanStatus", ctx, loan, staffID)
+	ret := m.ctrl.Call(m, "UpdateLoanStatus", ctx, change)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateLoanStatus indicates an expected call of UpdateLoanStatus.
-func (mr *MockLoanRepoMockRecorder) UpdateLoanStatus(ctx, loan, staffID interface{}) *gomock.Call {
+func (mr *MockLoanRepoMockRecorder) UpdateLoanStatus(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoanStatus", reflect.TypeOf((*MockLoanRepo)(nil).UpdateLoanStatus), ctx, change)
+}
+
+// WithdrawInvestment mocks base method.
+func (m *MockLoanRepo) WithdrawInvestment(ctx context.Context, withdrawal *entity.InvestmentWithdrawal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawInvestment", ctx, withdrawal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithdrawInvestment indicates an expected call of WithdrawInvestment.
+func (mr *MockLoanRepoMockRecorder) WithdrawInvestment(ctx, withdrawal interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoanStatus", reflect.TypeOf((*MockLoanRepo)(nil).UpdateLoanStatus), ctx, loan, staffID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawInvestment", reflect.TypeOf((*MockLoanRepo)(nil).WithdrawInvestment), ctx, withdrawal)
 }
@@ -0,0 +1,246 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notification_service.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entity "github.com/ferdikurniawan/loan-service/internal/entity"
+	mail "github.com/ferdikurniawan/loan-service/internal/pkg/mail"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockNotificationService is a mock of NotificationService interface.
+type MockNotificationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationServiceMockRecorder
+}
+
+// MockNotificationServiceMockRecorder is the mock recorder for MockNotificationService.
+type MockNotificationServiceMockRecorder struct {
+	mock *MockNotificationService
+}
+
+// NewMockNotificationService creates a new mock instance.
+func NewMockNotificationService(ctrl *gomock.Controller) *MockNotificationService {
+	mock := &MockNotificationService{ctrl: ctrl}
+	mock.recorder = &MockNotificationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationService) EXPECT() *MockNotificationServiceMockRecorder {
+	return m.recorder
+}
+
+// GetContact mocks base method.
+func (m *MockNotificationService) GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContact", ctx, investorID)
+	ret0, _ := ret[0].(*entity.InvestorContact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContact indicates an expected call of GetContact.
+func (mr *MockNotificationServiceMockRecorder) GetContact(ctx, investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContact", reflect.TypeOf((*MockNotificationService)(nil).GetContact), ctx, investorID)
+}
+
+// ListLoanNotifications mocks base method.
+func (m *MockNotificationService) ListLoanNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoanNotifications", ctx, loanID)
+	ret0, _ := ret[0].([]entity.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoanNotifications indicates an expected call of ListLoanNotifications.
+func (mr *MockNotificationServiceMockRecorder) ListLoanNotifications(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoanNotifications", reflect.TypeOf((*MockNotificationService)(nil).ListLoanNotifications), ctx, loanID)
+}
+
+// SaveContact mocks base method.
+func (m *MockNotificationService) SaveContact(ctx context.Context, contactRequest entity.ContactUpdateRequest) (*entity.InvestorContact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveContact", ctx, contactRequest)
+	ret0, _ := ret[0].(*entity.InvestorContact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveContact indicates an expected call of SaveContact.
+func (mr *MockNotificationServiceMockRecorder) SaveContact(ctx, contactRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveContact", reflect.TypeOf((*MockNotificationService)(nil).SaveContact), ctx, contactRequest)
+}
+
+// SendPending mocks base method.
+func (m *MockNotificationService) SendPending(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPending", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendPending indicates an expected call of SendPending.
+func (mr *MockNotificationServiceMockRecorder) SendPending(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPending", reflect.TypeOf((*MockNotificationService)(nil).SendPending), ctx)
+}
+
+// MockNotificationRepo is a mock of NotificationRepo interface.
+type MockNotificationRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepoMockRecorder
+}
+
+// MockNotificationRepoMockRecorder is the mock recorder for MockNotificationRepo.
+type MockNotificationRepoMockRecorder struct {
+	mock *MockNotificationRepo
+}
+
+// NewMockNotificationRepo creates a new mock instance.
+func NewMockNotificationRepo(ctrl *gomock.Controller) *MockNotificationRepo {
+	mock := &MockNotificationRepo{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepo) EXPECT() *MockNotificationRepoMockRecorder {
+	return m.recorder
+}
+
+// ClaimNotifications mocks base method.
+func (m *MockNotificationRepo) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimNotifications", ctx, limit, lease)
+	ret0, _ := ret[0].([]entity.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimNotifications indicates an expected call of ClaimNotifications.
+func (mr *MockNotificationRepoMockRecorder) ClaimNotifications(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimNotifications", reflect.TypeOf((*MockNotificationRepo)(nil).ClaimNotifications), ctx, limit, lease)
+}
+
+// GetContact mocks base method.
+func (m *MockNotificationRepo) GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContact", ctx, investorID)
+	ret0, _ := ret[0].(*entity.InvestorContact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContact indicates an expected call of GetContact.
+func (mr *MockNotificationRepoMockRecorder) GetContact(ctx, investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContact", reflect.TypeOf((*MockNotificationRepo)(nil).GetContact), ctx, investorID)
+}
+
+// ListNotifications mocks base method.
+func (m *MockNotificationRepo) ListNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, loanID)
+	ret0, _ := ret[0].([]entity.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockNotificationRepoMockRecorder) ListNotifications(ctx, loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockNotificationRepo)(nil).ListNotifications), ctx, loanID)
+}
+
+// MarkFailed mocks base method.
+func (m *MockNotificationRepo) MarkFailed(ctx context.Context, notificationID uuid.UUID, reason string, retryAt time.Time, giveUp bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, notificationID, reason, retryAt, giveUp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockNotificationRepoMockRecorder) MarkFailed(ctx, notificationID, reason, retryAt, giveUp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockNotificationRepo)(nil).MarkFailed), ctx, notificationID, reason, retryAt, giveUp)
+}
+
+// MarkSent mocks base method.
+func (m *MockNotificationRepo) MarkSent(ctx context.Context, notificationID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSent", ctx, notificationID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSent indicates an expected call of MarkSent.
+func (mr *MockNotificationRepoMockRecorder) MarkSent(ctx, notificationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSent", reflect.TypeOf((*MockNotificationRepo)(nil).MarkSent), ctx, notificationID)
+}
+
+// SaveContact mocks base method.
+func (m *MockNotificationRepo) SaveContact(ctx context.Context, contact *entity.InvestorContact) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveContact", ctx, contact)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveContact indicates an expected call of SaveContact.
+func (mr *MockNotificationRepoMockRecorder) SaveContact(ctx, contact interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveContact", reflect.TypeOf((*MockNotificationRepo)(nil).SaveContact), ctx, contact)
+}
+
+// MockEmailSender is a mock of EmailSender interface.
+type MockEmailSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailSenderMockRecorder
+}
+
+// MockEmailSenderMockRecorder is the mock recorder for MockEmailSender.
+type MockEmailSenderMockRecorder struct {
+	mock *MockEmailSender
+}
+
+// NewMockEmailSender creates a new mock instance.
+func NewMockEmailSender(ctrl *gomock.Controller) *MockEmailSender {
+	mock := &MockEmailSender{ctrl: ctrl}
+	mock.recorder = &MockEmailSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailSender) EXPECT() *MockEmailSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockEmailSender) Send(ctx context.Context, msg mail.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockEmailSenderMockRecorder) Send(ctx, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockEmailSender)(nil).Send), ctx, msg)
+}
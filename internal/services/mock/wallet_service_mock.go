@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: wallet_service.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entity "github.com/ferdikurniawan/loan-service/internal/entity"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockWalletService is a mock of WalletService interface.
+type MockWalletService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWalletServiceMockRecorder
+}
+
+// MockWalletServiceMockRecorder is the mock recorder for MockWalletService.
+type MockWalletServiceMockRecorder struct {
+	mock *MockWalletService
+}
+
+// NewMockWalletService creates a new mock instance.
+func NewMockWalletService(ctrl *gomock.Controller) *MockWalletService {
+	mock := &MockWalletService{ctrl: ctrl}
+	mock.recorder = &MockWalletServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWalletService) EXPECT() *MockWalletServiceMockRecorder {
+	return m.recorder
+}
+
+// ListWallets mocks base method.
+func (m *MockWalletService) ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWallets", ctx, investorID)
+	ret0, _ := ret[0].([]entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWallets indicates an expected call of ListWallets.
+func (mr *MockWalletServiceMockRecorder) ListWallets(ctx, investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWallets", reflect.TypeOf((*MockWalletService)(nil).ListWallets), ctx, investorID)
+}
+
+// TopUp mocks base method.
+func (m *MockWalletService) TopUp(ctx context.Context, topUpRequest entity.WalletTopUpRequest) (*entity.WalletTopUp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopUp", ctx, topUpRequest)
+	ret0, _ := ret[0].(*entity.WalletTopUp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopUp indicates an expected call of TopUp.
+func (mr *MockWalletServiceMockRecorder) TopUp(ctx, topUpRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopUp", reflect.TypeOf((*MockWalletService)(nil).TopUp), ctx, topUpRequest)
+}
+
+// MockWalletRepo is a mock of WalletRepo interface.
+type MockWalletRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockWalletRepoMockRecorder
+}
+
+// MockWalletRepoMockRecorder is the mock recorder for MockWalletRepo.
+type MockWalletRepoMockRecorder struct {
+	mock *MockWalletRepo
+}
+
+// NewMockWalletRepo creates a new mock instance.
+func NewMockWalletRepo(ctrl *gomock.Controller) *MockWalletRepo {
+	mock := &MockWalletRepo{ctrl: ctrl}
+	mock.recorder = &MockWalletRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWalletRepo) EXPECT() *MockWalletRepoMockRecorder {
+	return m.recorder
+}
+
+// ListWallets mocks base method.
+func (m *MockWalletRepo) ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWallets", ctx, investorID)
+	ret0, _ := ret[0].([]entity.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWallets indicates an expected call of ListWallets.
+func (mr *MockWalletRepoMockRecorder) ListWallets(ctx, investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWallets", reflect.TypeOf((*MockWalletRepo)(nil).ListWallets), ctx, investorID)
+}
+
+// TopUp mocks base method.
+func (m *MockWalletRepo) TopUp(ctx context.Context, topUp entity.WalletTopUp) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopUp", ctx, topUp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TopUp indicates an expected call of TopUp.
+func (mr *MockWalletRepoMockRecorder) TopUp(ctx, topUp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopUp", reflect.TypeOf((*MockWalletRepo)(nil).TopUp), ctx, topUp)
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	netmail "net/mail"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/mail"
+)
+
+const (
+	defaultNotificationBatchSize = 50
+	notificationLease            = 5 * time.Minute //how long a claimed notification is reserved for the job sending it
+	notificationMinBackoff       = 30 * time.Second
+	notificationMaxBackoff       = time.Hour
+	notificationMaxAttempts      = 8 //about an hour of retries before a notification is given up
+
+	defaultNotificationLinkTTL = 7 * 24 * time.Hour //the longest S3 presigns for
+)
+
+//go:embed templates/notification_*.html templates/notification_*.txt
+var notificationTemplates embed.FS
+
+var (
+	errNoRecipient = errors.New("investor has no e-mail address on file")
+	errNoAgreement = errors.New("loan has no agreement letter yet")
+)
+
+//go:generate mockgen -source=notification_service.go -package=mock -destination=mock/notification_service_mock.go
+type (
+	NotificationService interface {
+		SendPending(ctx context.Context) (int, error)
+		SaveContact(ctx context.Context, contactRequest entity.ContactUpdateRequest) (*entity.InvestorContact, error)
+		GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error)
+		ListLoanNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error)
+	}
+
+	notificationService struct {
+		repo      NotificationRepo
+		sender    EmailSender
+		documents DocumentStore
+		linkTTL   time.Duration
+		batchSize int
+	}
+
+	// NotificationRepo keeps the delivery state of notifications, they are queued by LoanRepo together with
+	// the status change they are about
+	NotificationRepo interface {
+		// ClaimNotifications reserves pending notifications due for sending for lease, oldest first
+		ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error)
+		MarkSent(ctx context.Context, notificationID uuid.UUID) error
+		MarkFailed(ctx context.Context, notificationID uuid.UUID, reason string, retryAt time.Time, giveUp bool) error
+		ListNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error)
+		SaveContact(ctx context.Context, contact *entity.InvestorContact) error
+		GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error)
+	}
+
+	// EmailSender delivers an e-mail, mail.SMTP in production
+	EmailSender interface {
+		Send(ctx context.Context, msg mail.Message) error
+	}
+)
+
+// notificationData is what the notification templates are filled with
+type notificationData struct {
+	Loan          entity.Loan
+	Amount        entity.Money
+	AgreementLink string
+}
+
+// NewNotificationService builds the notification service. Documents are linked in the messages with URLs signed by
+// documents, which can be opened from a mail client without logging in, valid for linkTTL.
+func NewNotificationService(repo NotificationRepo, sender EmailSender, documents DocumentStore, linkTTL time.Duration, batchSize int) *notificationService {
+	if linkTTL <= 0 {
+		linkTTL = defaultNotificationLinkTTL
+	}
+	if batchSize <= 0 {
+		batchSize = defaultNotificationBatchSize
+	}
+	return &notificationService{
+		repo:      repo,
+		sender:    sender,
+		documents: documents,
+		linkTTL:   linkTTL,
+		batchSize: batchSize,
+	}
+}
+
+// SendPending sends a batch of notifications due for sending and returns how many were sent. A failed notification is
+// retried later with an exponential backoff and given up after notificationMaxAttempts attempts.
+func (s *notificationService) SendPending(ctx context.Context) (int, error) {
+
+	notifications, err := s.repo.ClaimNotifications(ctx, s.batchSize, notificationLease)
+	if err != nil {
+		log.Printf("[SendPending] error claiming notifications: %s", err.Error())
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range notifications {
+		err := s.send(ctx, notification)
+		if err != nil {
+			log.Printf("[SendPending] error sending notification %s: %s", notification.ID, err.Error())
+
+			attempts := notification.Attempts + 1
+			retryAt := time.Now().Add(exponentialBackoff(notification.Attempts, notificationMinBackoff, notificationMaxBackoff))
+			if err := s.repo.MarkFailed(ctx, notification.ID, err.Error(), retryAt, attempts >= notificationMaxAttempts); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkSent(ctx, notification.ID); err != nil {
+			log.Printf("[SendPending] error marking notification %s sent: %s", notification.ID, err.Error())
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s *notificationService) send(ctx context.Context, notification entity.Notification) error {
+	if notification.Recipient == "" {
+		return errNoRecipient
+	}
+
+	key := agreementKey(notification)
+	if key == "" {
+		return errNoAgreement
+	}
+	agreementURL, err := s.documents.SignedURL(ctx, key, s.linkTTL)
+	if err != nil {
+		return err
+	}
+
+	msg, err := renderNotification(notification, agreementURL)
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, msg)
+}
+
+// agreementKey is the letter the loan is bound by: the signed copy uploaded on disbursement when there is one,
+// its latest generated letter otherwise. Without either the send fails and is retried like any other until given up.
+func agreementKey(notification entity.Notification) string {
+	if isDocumentKey(notification.Loan.AgreementLetter) {
+		return notification.Loan.AgreementLetter
+	}
+	return notification.AgreementKey
+}
+
+// renderNotification fills the templates of the notification's kind into an e-mail to its recipient
+func renderNotification(notification entity.Notification, agreementURL string) (mail.Message, error) {
+	name := "templates/notification_" + notification.Kind
+	data := notificationData{
+		Loan:          notification.Loan,
+		Amount:        notification.Amount,
+		AgreementLink: agreementURL,
+	}
+
+	text, err := texttemplate.ParseFS(notificationTemplates, name+".txt")
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("no template for notification kind %q: %w", notification.Kind, err)
+	}
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return mail.Message{}, err
+	}
+	if err := text.Execute(&body, data); err != nil {
+		return mail.Message{}, err
+	}
+
+	html, err := htmltemplate.ParseFS(notificationTemplates, name+".html")
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("no template for notification kind %q: %w", notification.Kind, err)
+	}
+	var htmlBody bytes.Buffer
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return mail.Message{}, err
+	}
+
+	return mail.Message{
+		To:      notification.Recipient,
+		Subject: subject.String(),
+		Text:    body.String(),
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+func (s *notificationService) SaveContact(ctx context.Context, contactRequest entity.ContactUpdateRequest) (*entity.InvestorContact, error) {
+
+	address, err := netmail.ParseAddress(strings.TrimSpace(contactRequest.Email))
+	if err != nil || address.Name != "" {
+		return nil, apperror.ErrInvalidEmail.Withf("%q is not an e-mail address", contactRequest.Email)
+	}
+
+	contact := entity.InvestorContact{
+		InvestorID: contactRequest.InvestorID,
+		Email:      address.Address,
+	}
+	if err := s.repo.SaveContact(ctx, &contact); err != nil {
+		log.Printf("[SaveContact] error saving contact: %s", err.Error())
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+func (s *notificationService) GetContact(ctx context.Context, investorID uuid.UUID) (*entity.InvestorContact, error) {
+
+	contact, err := s.repo.GetContact(ctx, investorID)
+	if err != nil {
+		log.Printf("[GetContact] error getting contact: %s", err.Error())
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+func (s *notificationService) ListLoanNotifications(ctx context.Context, loanID uuid.UUID) ([]entity.Notification, error) {
+
+	notifications, err := s.repo.ListNotifications(ctx, loanID)
+	if err != nil {
+		log.Printf("[ListLoanNotifications] error listing notifications: %s", err.Error())
+		return nil, err
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/mail"
+)
+
+func investedNotification(recipient string) entity.Notification {
+	loanID := uuid.New()
+	return entity.Notification{
+		ID:         uuid.New(),
+		Kind:       entity.NotificationKindLoanInvested,
+		LoanID:     loanID,
+		InvestorID: uuid.New(),
+		Status:     entity.NotificationStatusPending,
+		Recipient:  recipient,
+		Loan: entity.Loan{
+			ID:              loanID,
+			PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+			InterestRate:    1050,
+			TenorMonths:     12,
+			RepaymentMethod: entity.RepaymentMethodFlat,
+		},
+		Amount:       entity.NewMoney(250000, "IDR"),
+		AgreementKey: "agreements/3f9a.pdf",
+	}
+}
+
+func Test_SendPending(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockNotificationRepo(ctrl)
+	sender := mock.NewMockEmailSender(ctrl)
+	documents := mock.NewMockDocumentStore(ctrl)
+	ctx := context.Background()
+	signedURL := "https://documents.example.com/agreements/3f9a.pdf?expires=1717236000&signature=c2ln"
+
+	t.Run("send pending failed, error claiming notifications", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 0)
+		repo.EXPECT().ClaimNotifications(ctx, defaultNotificationBatchSize, notificationLease).Return(nil, errors.New("db error"))
+
+		_, err := svc.SendPending(ctx)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("send pending success, the investor gets a signed link to the letter that needs no login", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 48*time.Hour, 10)
+		notification := investedNotification("investor@example.com")
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{notification}, nil)
+		documents.EXPECT().SignedURL(ctx, "agreements/3f9a.pdf", 48*time.Hour).Return(signedURL, nil)
+		sender.EXPECT().Send(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+			assert.Equal(t, "investor@example.com", msg.To)
+			assert.Contains(t, msg.Subject, notification.LoanID.String())
+			assert.Contains(t, msg.Text, signedURL)
+			assert.Contains(t, msg.Text, "250000 IDR")
+			assert.Contains(t, msg.Text, "10.50% per annum")
+			assert.Contains(t, msg.HTML, `href="https://documents.example.com/agreements/3f9a.pdf?expires=1717236000&amp;signature=c2ln"`)
+			assert.NotContains(t, msg.Text, "/v1/loans/")
+			return nil
+		})
+		repo.EXPECT().MarkSent(ctx, notification.ID).Return(nil)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("send pending success, a failed send is retried later and the others still go out", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 10)
+		failing, next := investedNotification("down@example.com"), investedNotification("up@example.com")
+		failing.Attempts = 2
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{failing, next}, nil)
+		documents.EXPECT().SignedURL(ctx, gomock.Any(), defaultNotificationLinkTTL).Return(signedURL, nil).Times(2)
+		gomock.InOrder(
+			sender.EXPECT().Send(ctx, gomock.Any()).Return(errors.New("421 service not available")),
+			repo.EXPECT().MarkFailed(ctx, failing.ID, "421 service not available", gomock.Any(), false).
+				DoAndReturn(func(_ context.Context, _ uuid.UUID, _ string, retryAt time.Time, _ bool) error {
+					assert.WithinDuration(t, time.Now().Add(4*notificationMinBackoff), retryAt, 5*time.Second)
+					return nil
+				}),
+			sender.EXPECT().Send(ctx, gomock.Any()).Return(nil),
+			repo.EXPECT().MarkSent(ctx, next.ID).Return(nil),
+		)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("send pending success, a notification is given up after its last attempt", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 10)
+		notification := investedNotification("down@example.com")
+		notification.Attempts = notificationMaxAttempts - 1
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{notification}, nil)
+		documents.EXPECT().SignedURL(ctx, gomock.Any(), gomock.Any()).Return(signedURL, nil)
+		sender.EXPECT().Send(ctx, gomock.Any()).Return(errors.New("550 mailbox unavailable"))
+		repo.EXPECT().MarkFailed(ctx, notification.ID, "550 mailbox unavailable", gomock.Any(), true).Return(nil)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, sent)
+	})
+
+	t.Run("send pending success, an investor without an e-mail address is not sent anything", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 10)
+		notification := investedNotification("")
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{notification}, nil)
+		repo.EXPECT().MarkFailed(ctx, notification.ID, errNoRecipient.Error(), gomock.Any(), false).Return(nil)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, sent)
+	})
+
+	t.Run("send pending success, the signed letter uploaded on disbursement is linked to", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 10)
+		notification := investedNotification("investor@example.com")
+		notification.AgreementKey = ""
+		notification.Loan.AgreementLetter = "agreements/signed.pdf"
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{notification}, nil)
+		documents.EXPECT().SignedURL(ctx, "agreements/signed.pdf", defaultNotificationLinkTTL).Return(signedURL, nil)
+		sender.EXPECT().Send(ctx, gomock.Any()).Return(nil)
+		repo.EXPECT().MarkSent(ctx, notification.ID).Return(nil)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("send pending success, a loan without an agreement letter yet fails the attempt to retry it later", func(t *testing.T) {
+		svc := NewNotificationService(repo, sender, documents, 0, 10)
+		notification := investedNotification("investor@example.com")
+		notification.AgreementKey = ""
+		notification.Loan.AgreementLetter = agreementLink(notification.LoanID)
+
+		repo.EXPECT().ClaimNotifications(ctx, 10, notificationLease).Return([]entity.Notification{notification}, nil)
+		repo.EXPECT().MarkFailed(ctx, notification.ID, errNoAgreement.Error(), gomock.Any(), false).Return(nil)
+
+		sent, err := svc.SendPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, sent)
+	})
+}
+
+func Test_SaveContact(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockNotificationRepo(ctrl)
+	svc := NewNotificationService(repo, mock.NewMockEmailSender(ctrl), mock.NewMockDocumentStore(ctrl), 0, 0)
+	ctx := context.Background()
+	investorID := uuid.New()
+
+	t.Run("save contact failed, not an e-mail address", func(t *testing.T) {
+		for _, email := range []string{"", "investor", "investor@", "Investor <investor@example.com>"} {
+			_, err := svc.SaveContact(ctx, entity.ContactUpdateRequest{InvestorID: investorID, Email: email})
+			assert.ErrorIs(t, err, apperror.ErrInvalidEmail, email)
+		}
+	})
+
+	t.Run("save contact success", func(t *testing.T) {
+		repo.EXPECT().SaveContact(ctx, &entity.InvestorContact{InvestorID: investorID, Email: "investor@example.com"}).Return(nil)
+
+		contact, err := svc.SaveContact(ctx, entity.ContactUpdateRequest{InvestorID: investorID, Email: " investor@example.com "})
+		assert.Nil(t, err)
+		assert.Equal(t, "investor@example.com", contact.Email)
+	})
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+const (
+	defaultOutboxBatchSize = 100
+	outboxLease            = time.Minute //how long a claimed event is reserved for the relay publishing it
+	outboxMinBackoff       = time.Second
+	outboxMaxBackoff       = 10 * time.Minute
+)
+
+//go:generate mockgen -source=outbox_service.go -package=mock -destination=mock/outbox_service_mock.go
+type (
+	OutboxService interface {
+		RelayEvents(ctx context.Context) (int, error)
+	}
+
+	outboxService struct {
+		repo      OutboxRepo
+		publisher EventPublisher
+		batchSize int
+	}
+
+	OutboxRepo interface {
+		// ClaimEvents reserves unpublished events for lease, oldest first and at most one per loan
+		ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxEvent, error)
+		MarkPublished(ctx context.Context, eventID uuid.UUID) error
+		MarkFailed(ctx context.Context, eventID uuid.UUID, reason string, retryAt time.Time) error
+	}
+
+	// EventPublisher delivers an event envelope to its consumers, events with the same key must stay in order
+	EventPublisher interface {
+		Publish(ctx context.Context, key string, body []byte) error
+	}
+)
+
+func NewOutboxService(repo OutboxRepo, publisher EventPublisher, batchSize int) *outboxService {
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	return &outboxService{
+		repo:      repo,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// RelayEvents publishes a batch of pending outbox events and returns how many were published. Delivery is at least
+// once: an event published right before a crash is published again, consumers deduplicate on event_id.
+// A failed event is retried later with an exponential backoff and holds back the next events of its loan meanwhile.
+func (s *outboxService) RelayEvents(ctx context.Context) (int, error) {
+
+	events, err := s.repo.ClaimEvents(ctx, s.batchSize, outboxLease)
+	if err != nil {
+		log.Printf("[RelayEvents] error claiming events: %s", err.Error())
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err == nil {
+			err = s.publisher.Publish(ctx, event.AggregateID.String(), body)
+		}
+		if err != nil {
+			log.Printf("[RelayEvents] error publishing event %s: %s", event.ID, err.Error())
+			if err := s.repo.MarkFailed(ctx, event.ID, err.Error(), time.Now().Add(exponentialBackoff(event.Attempts, outboxMinBackoff, outboxMaxBackoff))); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkPublished(ctx, event.ID); err != nil {
+			log.Printf("[RelayEvents] error marking event %s published: %s", event.ID, err.Error())
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// exponentialBackoff doubles the delay after every failed attempt, starting from min and up to max
+func exponentialBackoff(attempts int, min, max time.Duration) time.Duration {
+	backoff := min
+	for i := 0; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
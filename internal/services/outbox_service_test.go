@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/eventbus"
+)
+
+func loanEvent(t *testing.T, to string) entity.OutboxEvent {
+	t.Helper()
+
+	event, err := entity.NewLoanStatusEvent(entity.LoanStatusChanged{
+		LoanID:          uuid.New(),
+		BorrowerID:      uuid.New(),
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		From:            entity.LoanStatusApproved,
+		To:              to,
+	}, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	return event
+}
+
+func Test_RelayEvents(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockOutboxRepo(ctrl)
+	ctx := context.Background()
+
+	t.Run("relay events failed, error claiming events", func(t *testing.T) {
+		svc := NewOutboxService(repo, eventbus.NewMemory(), 0)
+		repo.EXPECT().ClaimEvents(ctx, defaultOutboxBatchSize, outboxLease).Return(nil, errors.New("db error"))
+
+		_, err := svc.RelayEvents(ctx)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("relay events success, events are published as their envelope in order", func(t *testing.T) {
+		publisher := eventbus.NewMemory()
+		svc := NewOutboxService(repo, publisher, 10)
+		invested, disbursed := loanEvent(t, entity.LoanStatusInvested), loanEvent(t, entity.LoanStatusDisbursed)
+
+		repo.EXPECT().ClaimEvents(ctx, 10, outboxLease).Return([]entity.OutboxEvent{invested, disbursed}, nil)
+		gomock.InOrder(
+			repo.EXPECT().MarkPublished(ctx, invested.ID).Return(nil),
+			repo.EXPECT().MarkPublished(ctx, disbursed.ID).Return(nil),
+		)
+
+		published, err := svc.RelayEvents(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, published)
+
+		messages := publisher.Messages()
+		assert.Len(t, messages, 2)
+		assert.Equal(t, invested.AggregateID.String(), messages[0].Key)
+
+		var envelope map[string]any
+		assert.Nil(t, json.Unmarshal(messages[0].Body, &envelope))
+		assert.Equal(t, "loan.invested", envelope["event_type"])
+		assert.Equal(t, float64(entity.LoanEventVersion), envelope["event_version"])
+		assert.Equal(t, invested.ID.String(), envelope["event_id"])
+		assert.Equal(t, "invested", envelope["payload"].(map[string]any)["status"])
+		assert.NotContains(t, envelope, "Attempts")
+	})
+
+	t.Run("relay events success, a failed event is retried later and the others still go out", func(t *testing.T) {
+		publisher := mock.NewMockEventPublisher(ctrl)
+		svc := NewOutboxService(repo, publisher, 10)
+		failing, next := loanEvent(t, entity.LoanStatusApproved), loanEvent(t, entity.LoanStatusCancelled)
+		failing.Attempts = 3
+
+		repo.EXPECT().ClaimEvents(ctx, 10, outboxLease).Return([]entity.OutboxEvent{failing, next}, nil)
+		publisher.EXPECT().Publish(ctx, failing.AggregateID.String(), gomock.Any()).Return(errors.New("broker unavailable"))
+		repo.EXPECT().MarkFailed(ctx, failing.ID, "broker unavailable", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, _ string, retryAt time.Time) error {
+				assert.WithinDuration(t, time.Now().Add(8*time.Second), retryAt, time.Second)
+				return nil
+			})
+		publisher.EXPECT().Publish(ctx, next.AggregateID.String(), gomock.Any()).Return(nil)
+		repo.EXPECT().MarkPublished(ctx, next.ID).Return(nil)
+
+		published, err := svc.RelayEvents(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, published)
+	})
+}
+
+func Test_ExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Second, exponentialBackoff(0, outboxMinBackoff, outboxMaxBackoff))
+	assert.Equal(t, 2*time.Second, exponentialBackoff(1, outboxMinBackoff, outboxMaxBackoff))
+	assert.Equal(t, 64*time.Second, exponentialBackoff(6, outboxMinBackoff, outboxMaxBackoff))
+	assert.Equal(t, outboxMaxBackoff, exponentialBackoff(10, outboxMinBackoff, outboxMaxBackoff))
+	assert.Equal(t, outboxMaxBackoff, exponentialBackoff(1000, outboxMinBackoff, outboxMaxBackoff))
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func (s *loanService) RepayLoan(ctx context.Context, loanRepaymentRequest entity.LoanRepaymentRequest) (*entity.Repayment, error) {
+
+	if err := validateAmount(loanRepaymentRequest.Amount); err != nil {
+		return nil, err
+	}
+
+	loanID := uuid.MustParse(loanRepaymentRequest.LoanID)
+
+	currentLoan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		log.Printf("[RepayLoan] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	if err := checkRepayable(currentLoan.Status); err != nil {
+		return nil, err
+	}
+
+	if !loanRepaymentRequest.Amount.SameCurrency(currentLoan.PrincipalAmount) {
+		return nil, apperror.ErrCurrencyMismatch
+	}
+
+	instalments, err := s.repo.ListInstalments(ctx, loanID)
+	if err != nil {
+		log.Printf("[RepayLoan] error listing instalments: %s", err.Error())
+		return nil, err
+	}
+
+	paidAt := loanRepaymentRequest.PaidAt
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	repayment := entity.Repayment{
+		ID:         uuid.New(),
+		LoanID:     loanID,
+		Amount:     loanRepaymentRequest.Amount,
+		PaidAt:     paidAt,
+		RecordedBy: uuid.MustParse(loanRepaymentRequest.StaffID),
+		LoanStatus: currentLoan.Status,
+	}
+
+	before, after, err := allocateRepayment(instalments, repayment.Amount.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range after {
+		repayment.Allocations = append(repayment.Allocations, entity.RepaymentAllocation{
+			InstalmentNumber: after[i].Number,
+			Fee:              entity.NewMoney(after[i].FeePaid.Amount-before[i].FeePaid.Amount, repayment.Amount.Currency),
+			Interest:         entity.NewMoney(after[i].InterestPaid.Amount-before[i].InterestPaid.Amount, repayment.Amount.Currency),
+			Principal:        entity.NewMoney(after[i].PrincipalPaid.Amount-before[i].PrincipalPaid.Amount, repayment.Amount.Currency),
+		})
+	}
+
+	investments, err := s.repo.ListInvestments(ctx, entity.InvestmentFilter{LoanID: loanID})
+	if err != nil {
+		log.Printf("[RepayLoan] error listing investments: %s", err.Error())
+		return nil, err
+	}
+	repayment.Payouts = investorPayouts(repayment, investments)
+
+	record := entity.RepaymentRecord{
+		Before:        before,
+		After:         after,
+		LoanUpdatedAt: currentLoan.UpdatedAt,
+	}
+
+	//the first repayment starts the repaying phase, the one settling the last instalment closes the loan
+	nextStatus := entity.LoanStatusRepaying
+	if fullyRepaid(instalments, after) {
+		nextStatus = entity.LoanStatusClosed
+	}
+	if nextStatus != currentLoan.Status {
+		if err := checkTransition(currentLoan.Status, nextStatus); err != nil {
+			return nil, err
+		}
+		record.StatusChange = &entity.LoanStatusChange{
+			LoanID:    loanID,
+			From:      currentLoan.Status,
+			To:        nextStatus,
+			UpdatedBy: repayment.RecordedBy,
+			Reason:    repaymentReason(nextStatus),
+		}
+		repayment.LoanStatus = nextStatus
+	}
+	record.Repayment = repayment
+
+	err = s.repo.RecordRepayment(ctx, &record)
+	if err != nil {
+		log.Printf("[RepayLoan] error recording repayment: %s", err.Error())
+		return nil, err
+	}
+
+	return &record.Repayment, nil
+}
+
+// investorPayouts passes the principal and interest of a repayment on to the investments, pro rata to their
+// amount. Both are split with the largest remainder method so the payouts add up exactly to what was repaid,
+// the leftover minor units going to the investments with the largest remainders, ties to the earliest one.
+func investorPayouts(repayment entity.Repayment, investments []entity.InvestmentDetail) []entity.InvestorPayout {
+	var principal, interest, invested int64
+	for _, alloc := range repayment.Allocations {
+		principal += alloc.Principal.Amount
+		interest += alloc.Interest.Amount
+	}
+
+	weights := make([]int64, len(investments))
+	for i, inv := range investments {
+		weights[i] = inv.Amount.Amount
+		invested += inv.Amount.Amount
+	}
+
+	principalShares := allocateProRata(principal, weights, invested)
+	interestShares := allocateProRata(interest, weights, invested)
+
+	currency := repayment.Amount.Currency
+	payouts := make([]entity.InvestorPayout, 0, len(investments))
+	for i, inv := range investments {
+		payouts = append(payouts, entity.InvestorPayout{
+			ID:           uuid.New(),
+			RepaymentID:  repayment.ID,
+			LoanID:       repayment.LoanID,
+			InvestmentID: inv.ID,
+			InvestorID:   inv.InvestorID,
+			Principal:    entity.NewMoney(principalShares[i], currency),
+			Interest:     entity.NewMoney(interestShares[i], currency),
+			Amount:       entity.NewMoney(principalShares[i]+interestShares[i], currency),
+			PaidAt:       repayment.PaidAt,
+		})
+	}
+
+	return payouts
+}
+
+func (s *loanService) ListInvestorPayouts(ctx context.Context, filter entity.PayoutFilter) ([]entity.InvestorPayout, error) {
+	payouts, err := s.repo.ListPayouts(ctx, filter)
+	if err != nil {
+		log.Printf("[ListInvestorPayouts] error listing payouts: %s", err.Error())
+		return nil, err
+	}
+	return payouts, nil
+}
+
+func repaymentReason(status string) string {
+	if status == entity.LoanStatusClosed {
+		return "loan fully repaid"
+	}
+	return "first repayment received"
+}
+
+// allocateRepayment spreads amount over the instalments in due order; within an instalment it settles the fee
+// first, then the interest, then the principal. It returns the instalments it touched, before and after.
+func allocateRepayment(instalments []entity.LoanInstalment, amount int64) ([]entity.LoanInstalment, []entity.LoanInstalment, error) {
+	var outstanding int64
+	for _, inst := range instalments {
+		outstanding += inst.Outstanding()
+	}
+	if amount > outstanding {
+		return nil, nil, apperror.ErrRepaymentExceedsOutstanding.Withf("repayment is larger than the %d left to repay", outstanding)
+	}
+
+	var before, after []entity.LoanInstalment
+	for _, inst := range instalments {
+		if amount == 0 {
+			break
+		}
+		if inst.Outstanding() == 0 {
+			continue
+		}
+
+		paid := inst
+		amount = settle(&paid.FeePaid, paid.Fee, amount)
+		amount = settle(&paid.InterestPaid, paid.Interest, amount)
+		amount = settle(&paid.PrincipalPaid, paid.Principal, amount)
+		paid.Status = instalmentStatus(paid)
+
+		before = append(before, inst)
+		after = append(after, paid)
+	}
+
+	return before, after, nil
+}
+
+// settle moves up to amount into paid without exceeding due, and returns what is left of amount
+func settle(paid *entity.Money, due entity.Money, amount int64) int64 {
+	part := due.Amount - paid.Amount
+	if part > amount {
+		part = amount
+	}
+	paid.Amount += part
+	paid.Currency = due.Currency
+	return amount - part
+}
+
+func instalmentStatus(inst entity.LoanInstalment) string {
+	switch {
+	case inst.Outstanding() == 0:
+		return entity.InstalmentStatusPaid
+	case inst.PrincipalPaid.Amount+inst.InterestPaid.Amount+inst.FeePaid.Amount > 0:
+		return entity.InstalmentStatusPartiallyPaid
+	}
+	return entity.InstalmentStatusDue
+}
+
+// fullyRepaid tells whether every instalment is settled once the updated ones replace their previous state
+func fullyRepaid(instalments, updated []entity.LoanInstalment) bool {
+	byNumber := map[int]entity.LoanInstalment{}
+	for _, inst := range updated {
+		byNumber[inst.Number] = inst
+	}
+
+	for _, inst := range instalments {
+		if u, ok := byNumber[inst.Number]; ok {
+			inst = u
+		}
+		if inst.Outstanding() > 0 {
+			return false
+		}
+	}
+	return true
+}
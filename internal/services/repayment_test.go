@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func instalment(number int, principal, interest, fee int64) entity.LoanInstalment {
+	return entity.LoanInstalment{
+		Number:        number,
+		Principal:     entity.NewMoney(principal, "IDR"),
+		Interest:      entity.NewMoney(interest, "IDR"),
+		Fee:           entity.NewMoney(fee, "IDR"),
+		Amount:        entity.NewMoney(principal+interest+fee, "IDR"),
+		PrincipalPaid: entity.NewMoney(0, "IDR"),
+		InterestPaid:  entity.NewMoney(0, "IDR"),
+		FeePaid:       entity.NewMoney(0, "IDR"),
+	}
+}
+
+func Test_AllocateRepayment(t *testing.T) {
+	t.Parallel()
+
+	instalments := []entity.LoanInstalment{
+		instalment(1, 100, 10, 5),
+		instalment(2, 100, 10, 0),
+	}
+
+	t.Run("fees, then interest, then principal", func(t *testing.T) {
+		before, after, err := allocateRepayment(instalments, 12)
+		assert.Nil(t, err)
+		assert.Len(t, after, 1)
+		assert.Equal(t, int64(0), before[0].FeePaid.Amount)
+		assert.Equal(t, int64(5), after[0].FeePaid.Amount)
+		assert.Equal(t, int64(7), after[0].InterestPaid.Amount)
+		assert.Equal(t, int64(0), after[0].PrincipalPaid.Amount)
+		assert.Equal(t, entity.InstalmentStatusPartiallyPaid, after[0].Status)
+	})
+
+	t.Run("spills over to the next instalment", func(t *testing.T) {
+		_, after, err := allocateRepayment(instalments, 130)
+		assert.Nil(t, err)
+		assert.Len(t, after, 2)
+		assert.Equal(t, entity.InstalmentStatusPaid, after[0].Status)
+		assert.Equal(t, int64(10), after[1].InterestPaid.Amount)
+		assert.Equal(t, int64(5), after[1].PrincipalPaid.Amount)
+		assert.False(t, fullyRepaid(instalments, after))
+	})
+
+	t.Run("skips settled instalments", func(t *testing.T) {
+		_, paid, _ := allocateRepayment(instalments, 115)
+		current := []entity.LoanInstalment{paid[0], instalments[1]}
+
+		_, after, err := allocateRepayment(current, 110)
+		assert.Nil(t, err)
+		assert.Len(t, after, 1)
+		assert.Equal(t, 2, after[0].Number)
+		assert.True(t, fullyRepaid(current, after))
+	})
+
+	t.Run("refuses more than what is outstanding", func(t *testing.T) {
+		_, _, err := allocateRepayment(instalments, 226)
+		assert.True(t, errors.Is(err, apperror.ErrRepaymentExceedsOutstanding))
+	})
+}
+
+func Test_InvestorPayouts(t *testing.T) {
+	t.Parallel()
+
+	repayment := entity.Repayment{
+		ID:     uuid.New(),
+		Amount: entity.NewMoney(106, "IDR"),
+		Allocations: []entity.RepaymentAllocation{
+			{InstalmentNumber: 1, Fee: entity.NewMoney(5, "IDR"), Interest: entity.NewMoney(1, "IDR"), Principal: entity.NewMoney(100, "IDR")},
+		},
+	}
+	investments := []entity.InvestmentDetail{
+		{LoanInvestment: entity.LoanInvestment{ID: uuid.New(), Amount: entity.NewMoney(1, "IDR")}},
+		{LoanInvestment: entity.LoanInvestment{ID: uuid.New(), Amount: entity.NewMoney(1, "IDR")}},
+		{LoanInvestment: entity.LoanInvestment{ID: uuid.New(), Amount: entity.NewMoney(1, "IDR")}},
+	}
+
+	payouts := investorPayouts(repayment, investments)
+	assert.Len(t, payouts, 3)
+
+	// fees stay with the platform, 100 principal and 1 interest are split without losing a unit
+	var principal, interest int64
+	for i, payout := range payouts {
+		assert.Equal(t, investments[i].ID, payout.InvestmentID)
+		assert.Equal(t, repayment.ID, payout.RepaymentID)
+		principal += payout.Principal.Amount
+		interest += payout.Interest.Amount
+	}
+	assert.Equal(t, int64(100), principal)
+	assert.Equal(t, int64(1), interest)
+	assert.Equal(t, []int64{34, 33, 33}, []int64{payouts[0].Principal.Amount, payouts[1].Principal.Amount, payouts[2].Principal.Amount})
+	assert.Equal(t, []int64{1, 0, 0}, []int64{payouts[0].Interest.Amount, payouts[1].Interest.Amount, payouts[2].Interest.Amount})
+}
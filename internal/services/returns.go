@@ -0,0 +1,110 @@
+package services
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+// All amounts handled here are integers in minor units, rates are entity.Rate basis points (1% = 100 bps).
+// Rounding is half up for single amounts and largest remainder for splits, so that splitting a total
+// never creates or loses a minor unit and the same input always gives the same split.
+
+const bpsDenominator = 10000
+
+// totalInterest is the interest charged on principal over the repayment schedule of the terms: the sum of the
+// instalments' interest, so that it is exactly what the borrower pays and the investors receive
+func totalInterest(principal entity.Money, rate entity.Rate, tenorMonths int, method string) entity.Money {
+	var interest int64
+	for _, amount := range scheduleAmounts(principal.Amount, rate, tenorMonths, method) {
+		interest += amount[1]
+	}
+	return entity.NewMoney(interest, principal.Currency)
+}
+
+// shareOf is the part of total that amount represents, as a rate rounded half up to the basis point
+func shareOf(amount, total entity.Money) entity.Rate {
+	if total.Amount <= 0 {
+		return 0
+	}
+	return entity.Rate(mulDivRound(amount.Amount, bpsDenominator, total.Amount))
+}
+
+// mulDivRound returns a*b/c rounded half up, computed without overflow
+func mulDivRound(a, b, c int64) int64 {
+	n := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	n.Mul(n, big.NewInt(2))
+	n.Add(n, big.NewInt(c))
+	n.Quo(n, new(big.Int).Mul(big.NewInt(c), big.NewInt(2)))
+	return n.Int64()
+}
+
+// allocateProRata splits total over weights in proportion weight/base. The shares add up to
+// total*sum(weights)/base rounded half up; the minor units left after flooring every share go to
+// the largest remainders, ties going to the earlier weight.
+func allocateProRata(total int64, weights []int64, base int64) []int64 {
+	shares := make([]int64, len(weights))
+	if base <= 0 || len(weights) == 0 {
+		return shares
+	}
+
+	type remainder struct {
+		index int
+		value *big.Int
+	}
+
+	var (
+		sumWeights int64
+		allocated  int64
+		remainders = make([]remainder, len(weights))
+		bigTotal   = big.NewInt(total)
+		bigBase    = big.NewInt(base)
+	)
+
+	for i, w := range weights {
+		sumWeights += w
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(bigTotal, big.NewInt(w)), bigBase, new(big.Int))
+		shares[i] = q.Int64()
+		allocated += shares[i]
+		remainders[i] = remainder{index: i, value: r}
+	}
+
+	target := mulDivRound(total, sumWeights, base)
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value.Cmp(remainders[j].value) > 0
+	})
+
+	for i := 0; allocated < target && i < len(remainders); i++ {
+		shares[remainders[i].index]++
+		allocated++
+	}
+
+	return shares
+}
+
+// investorReturns computes, for every investment of a loan, its share of the principal and of the loan's total
+// interest. investments must all belong to the loan and be in a stable order (invested_at, id), which decides ties.
+func investorReturns(principal, interest entity.Money, investments []entity.LoanInvestment) []entity.InvestorReturn {
+	weights := make([]int64, len(investments))
+	for i, inv := range investments {
+		weights[i] = inv.Amount.Amount
+	}
+
+	principalShares := allocateProRata(principal.Amount, weights, principal.Amount)
+	interestShares := allocateProRata(interest.Amount, weights, principal.Amount)
+
+	returns := make([]entity.InvestorReturn, len(investments))
+	for i, inv := range investments {
+		returns[i] = entity.InvestorReturn{
+			InvestmentID:   inv.ID,
+			InvestorID:     inv.InvestorID,
+			Amount:         inv.Amount,
+			PrincipalShare: entity.NewMoney(principalShares[i], principal.Currency),
+			InterestShare:  entity.NewMoney(interestShares[i], principal.Currency),
+			TotalReturn:    entity.NewMoney(principalShares[i]+interestShares[i], principal.Currency),
+		}
+	}
+
+	return returns
+}
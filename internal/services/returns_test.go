@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+func Test_TotalInterest(t *testing.T) {
+	t.Parallel()
+
+	// the rate is per annum: a year at 10% is 10%, half a year 5%
+	assert.Equal(t, entity.NewMoney(100000, "IDR"), totalInterest(entity.NewMoney(1000000, "IDR"), 1000, 12, entity.RepaymentMethodFlat))
+	assert.Equal(t, entity.NewMoney(105000, "IDR"), totalInterest(entity.NewMoney(1000000, "IDR"), 1050, 12, entity.RepaymentMethodFlat))
+	assert.Equal(t, entity.NewMoney(50000, "IDR"), totalInterest(entity.NewMoney(1000000, "IDR"), 1000, 6, entity.RepaymentMethodFlat))
+	// 333 * 10.5% = 34.965 -> 35 (half up)
+	assert.Equal(t, entity.NewMoney(35, "USD"), totalInterest(entity.NewMoney(333, "USD"), 1050, 12, entity.RepaymentMethodFlat))
+	// no overflow on large principals
+	assert.Equal(t, int64(900000000000000000), totalInterest(entity.NewMoney(9000000000000000000, "IDR"), 1000, 12, entity.RepaymentMethodFlat).Amount)
+	// no schedule without a tenor
+	assert.Equal(t, entity.NewMoney(0, "IDR"), totalInterest(entity.NewMoney(1000000, "IDR"), 1000, 0, entity.RepaymentMethodFlat))
+}
+
+func Test_TotalInterest_MatchesSchedule(t *testing.T) {
+	t.Parallel()
+
+	for _, method := range []string{entity.RepaymentMethodFlat, entity.RepaymentMethodAnnuity} {
+		loan := entity.Loan{PrincipalAmount: entity.NewMoney(1000000, "IDR"), InterestRate: 1200, TenorMonths: 6, RepaymentMethod: method}
+
+		var scheduled int64
+		for _, inst := range repaymentSchedule(&loan, time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC), 1) {
+			scheduled += inst.Interest.Amount
+		}
+
+		returns := totalInterest(loan.PrincipalAmount, loan.InterestRate, loan.TenorMonths, loan.RepaymentMethod)
+		assert.Equal(t, scheduled, returns.Amount, method)
+	}
+}
+
+func Test_ShareOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, entity.Rate(2500), shareOf(entity.NewMoney(250000, "IDR"), entity.NewMoney(1000000, "IDR")))
+	// 1/3 -> 33.33%
+	assert.Equal(t, entity.Rate(3333), shareOf(entity.NewMoney(1, "IDR"), entity.NewMoney(3, "IDR")))
+	assert.Equal(t, entity.Rate(0), shareOf(entity.NewMoney(1, "IDR"), entity.NewMoney(0, "IDR")))
+}
+
+func Test_AllocateProRata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("even split", func(t *testing.T) {
+		assert.Equal(t, []int64{50, 50}, allocateProRata(100, []int64{500, 500}, 1000))
+	})
+
+	t.Run("remainder goes to largest fraction", func(t *testing.T) {
+		// 100 split 1:1:1 -> 33.33 each, one unit left which goes to the first (tie)
+		shares := allocateProRata(100, []int64{1, 1, 1}, 3)
+		assert.Equal(t, []int64{34, 33, 33}, shares)
+	})
+
+	t.Run("largest remainder wins over order", func(t *testing.T) {
+		// exact shares 10*1/6=1.67, 10*2/6=3.33, 10*3/6=5 -> floors 1,3,5 and one unit to the first
+		shares := allocateProRata(10, []int64{1, 2, 3}, 6)
+		assert.Equal(t, []int64{2, 3, 5}, shares)
+	})
+
+	t.Run("partially funded base keeps unfunded part unallocated", func(t *testing.T) {
+		shares := allocateProRata(100, []int64{250}, 1000)
+		assert.Equal(t, []int64{25}, shares)
+	})
+
+	t.Run("shares always sum to total when fully funded", func(t *testing.T) {
+		weights := []int64{333333, 333333, 333334}
+		shares := allocateProRata(100001, weights, 1000000)
+		var sum int64
+		for _, s := range shares {
+			sum += s
+		}
+		assert.Equal(t, int64(100001), sum)
+	})
+}
+
+func Test_InvestorReturns(t *testing.T) {
+	t.Parallel()
+
+	investments := []entity.LoanInvestment{
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: entity.NewMoney(333, "IDR")},
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: entity.NewMoney(333, "IDR")},
+		{ID: uuid.New(), InvestorID: uuid.New(), Amount: entity.NewMoney(334, "IDR")},
+	}
+
+	// 1000 at 10.5% -> 105 interest
+	returns := investorReturns(entity.NewMoney(1000, "IDR"), entity.NewMoney(105, "IDR"), investments)
+
+	var principal, interest int64
+	for i, ret := range returns {
+		assert.Equal(t, investments[i].Amount, ret.PrincipalShare)
+		assert.Equal(t, ret.PrincipalShare.Amount+ret.InterestShare.Amount, ret.TotalReturn.Amount)
+		assert.Equal(t, "IDR", ret.InterestShare.Currency)
+		principal += ret.PrincipalShare.Amount
+		interest += ret.InterestShare.Amount
+	}
+
+	assert.Equal(t, int64(1000), principal)
+	assert.Equal(t, int64(105), interest)
+	assert.Equal(t, []int64{35, 35, 35}, []int64{returns[0].InterestShare.Amount, returns[1].InterestShare.Amount, returns[2].InterestShare.Amount})
+}
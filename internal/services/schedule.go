@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+const (
+	maxTenorMonths = 360
+
+	// monthlyRateDenominator turns an annual rate in basis points into a monthly fraction
+	monthlyRateDenominator = bpsDenominator * 12
+
+	defaultFirstDueAfterMonths = 1
+)
+
+func validateTerms(tenorMonths int, method string) error {
+	if tenorMonths < 1 || tenorMonths > maxTenorMonths {
+		return apperror.ErrInvalidTenor
+	}
+	if method != entity.RepaymentMethodFlat && method != entity.RepaymentMethodAnnuity {
+		return apperror.ErrInvalidRepaymentMethod
+	}
+	return nil
+}
+
+func (s *loanService) GetLoanSchedule(ctx context.Context, loanID uuid.UUID) (*entity.LoanSchedule, error) {
+
+	loan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		log.Printf("[GetLoanSchedule] error getting loan detail: %s", err.Error())
+		return nil, err
+	}
+
+	instalments, err := s.repo.ListInstalments(ctx, loanID)
+	if err != nil {
+		log.Printf("[GetLoanSchedule] error listing instalments: %s", err.Error())
+		return nil, err
+	}
+
+	if len(instalments) == 0 {
+		return nil, apperror.ErrScheduleNotAvailable
+	}
+
+	return newLoanSchedule(loan, instalments), nil
+}
+
+func newLoanSchedule(loan *entity.Loan, instalments []entity.LoanInstalment) *entity.LoanSchedule {
+	schedule := &entity.LoanSchedule{
+		LoanID:          loan.ID,
+		BorrowerID:      loan.BorrowerID,
+		LoanStatus:      loan.Status,
+		RepaymentMethod: loan.RepaymentMethod,
+		TenorMonths:     loan.TenorMonths,
+		TotalPrincipal:  entity.NewMoney(0, loan.PrincipalAmount.Currency),
+		TotalInterest:   entity.NewMoney(0, loan.PrincipalAmount.Currency),
+		TotalAmount:     entity.NewMoney(0, loan.PrincipalAmount.Currency),
+		TotalPaid:       entity.NewMoney(0, loan.PrincipalAmount.Currency),
+		Instalments:     instalments,
+	}
+
+	for i, inst := range instalments {
+		schedule.Instalments[i].Status = instalmentStatus(inst)
+		schedule.TotalPrincipal.Amount += inst.Principal.Amount
+		schedule.TotalInterest.Amount += inst.Interest.Amount
+		schedule.TotalAmount.Amount += inst.Amount.Amount
+		schedule.TotalPaid.Amount += inst.PrincipalPaid.Amount + inst.InterestPaid.Amount + inst.FeePaid.Amount
+	}
+
+	return schedule
+}
+
+// repaymentSchedule splits the loan into TenorMonths monthly instalments, the first one due firstDueAfterMonths
+// after disburseAt. The interest rate is per annum. Every instalment is rounded to the minor unit and the last
+// one takes whatever is left, so the principals always add up exactly to the loan principal.
+func repaymentSchedule(loan *entity.Loan, disburseAt time.Time, firstDueAfterMonths int) []entity.LoanInstalment {
+	amounts := scheduleAmounts(loan.PrincipalAmount.Amount, loan.InterestRate, loan.TenorMonths, loan.RepaymentMethod)
+
+	currency := loan.PrincipalAmount.Currency
+	outstanding := loan.PrincipalAmount.Amount
+
+	instalments := make([]entity.LoanInstalment, len(amounts))
+	for i, amount := range amounts {
+		outstanding -= amount[0]
+		instalments[i] = entity.LoanInstalment{
+			LoanID:               loan.ID,
+			Number:               i + 1,
+			DueDate:              addMonths(disburseAt, firstDueAfterMonths+i),
+			Principal:            entity.NewMoney(amount[0], currency),
+			Interest:             entity.NewMoney(amount[1], currency),
+			Fee:                  entity.NewMoney(0, currency),
+			Amount:               entity.NewMoney(amount[0]+amount[1], currency),
+			OutstandingPrincipal: entity.NewMoney(outstanding, currency),
+			PrincipalPaid:        entity.NewMoney(0, currency),
+			InterestPaid:         entity.NewMoney(0, currency),
+			FeePaid:              entity.NewMoney(0, currency),
+			Status:               entity.InstalmentStatusDue,
+		}
+	}
+
+	return instalments
+}
+
+// scheduleAmounts is the principal and interest of every monthly instalment of the terms, none without a tenor
+func scheduleAmounts(principal int64, rate entity.Rate, tenor int, method string) [][2]int64 {
+	if tenor < 1 {
+		return nil
+	}
+	if method == entity.RepaymentMethodAnnuity {
+		return annuityAmounts(principal, rate, tenor)
+	}
+	return flatAmounts(principal, rate, tenor)
+}
+
+// flatAmounts charges interest on the original principal for the whole tenor and spreads principal and
+// interest evenly, the last instalment absorbing the rounding
+func flatAmounts(principal int64, rate entity.Rate, tenor int) [][2]int64 {
+	interest := mulDivRound(principal, rate.Bps()*int64(tenor), monthlyRateDenominator)
+
+	amounts := make([][2]int64, tenor)
+	for i := range amounts {
+		amounts[i] = [2]int64{principal / int64(tenor), interest / int64(tenor)}
+	}
+	amounts[tenor-1][0] += principal % int64(tenor)
+	amounts[tenor-1][1] += interest % int64(tenor)
+
+	return amounts
+}
+
+// annuityAmounts computes the constant instalment P*r/(1-(1+r)^-n) with r the monthly rate, exactly with
+// rationals and rounded half up once. Each month's interest is charged on the outstanding principal and the
+// rest of the instalment repays principal; the last instalment repays whatever principal is left.
+func annuityAmounts(principal int64, rate entity.Rate, tenor int) [][2]int64 {
+	if rate.Bps() == 0 {
+		return flatAmounts(principal, rate, tenor)
+	}
+
+	r := big.NewRat(rate.Bps(), monthlyRateDenominator)
+	growth := new(big.Rat).Add(big.NewRat(1, 1), r)
+	compounded := big.NewRat(1, 1)
+	for i := 0; i < tenor; i++ {
+		compounded.Mul(compounded, growth)
+	}
+
+	// P*r*(1+r)^n / ((1+r)^n - 1)
+	payment := new(big.Rat).Mul(new(big.Rat).SetInt64(principal), r)
+	payment.Mul(payment, compounded)
+	payment.Quo(payment, new(big.Rat).Sub(compounded, big.NewRat(1, 1)))
+	instalment := roundRat(payment)
+
+	amounts := make([][2]int64, tenor)
+	outstanding := principal
+	for i := range amounts {
+		interest := mulDivRound(outstanding, rate.Bps(), monthlyRateDenominator)
+		repaid := instalment - interest
+		if i == tenor-1 || repaid > outstanding {
+			repaid = outstanding
+		}
+		amounts[i] = [2]int64{repaid, interest}
+		outstanding -= repaid
+	}
+
+	return amounts
+}
+
+// roundRat rounds a non-negative rational half up to an integer
+func roundRat(x *big.Rat) int64 {
+	n := new(big.Int).Mul(x.Num(), big.NewInt(2))
+	n.Add(n, x.Denom())
+	n.Quo(n, new(big.Int).Mul(x.Denom(), big.NewInt(2)))
+	return n.Int64()
+}
+
+// addMonths moves t by months calendar months, clamping to the end of shorter months (Jan 31 + 1 month is Feb 28/29)
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+func Test_FlatSchedule(t *testing.T) {
+	t.Parallel()
+
+	loan := &entity.Loan{
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1050,
+		TenorMonths:     7,
+		RepaymentMethod: entity.RepaymentMethodFlat,
+	}
+
+	instalments := repaymentSchedule(loan, time.Date(2025, 5, 25, 0, 0, 0, 0, time.UTC), 1)
+	assert.Len(t, instalments, 7)
+
+	// 1,000,000 * 10.5% * 7/12 = 61,250
+	var principal, interest int64
+	for _, inst := range instalments {
+		principal += inst.Principal.Amount
+		interest += inst.Interest.Amount
+		assert.Equal(t, inst.Principal.Amount+inst.Interest.Amount, inst.Amount.Amount)
+	}
+	assert.Equal(t, int64(1000000), principal)
+	assert.Equal(t, int64(61250), interest)
+
+	assert.Equal(t, int64(142857), instalments[0].Principal.Amount)
+	assert.Equal(t, int64(142858), instalments[6].Principal.Amount)
+	assert.Equal(t, int64(0), instalments[6].OutstandingPrincipal.Amount)
+	assert.Equal(t, time.Date(2025, 6, 25, 0, 0, 0, 0, time.UTC), instalments[0].DueDate)
+	assert.Equal(t, time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), instalments[6].DueDate)
+}
+
+func Test_AnnuitySchedule(t *testing.T) {
+	t.Parallel()
+
+	loan := &entity.Loan{
+		PrincipalAmount: entity.NewMoney(1000000, "IDR"),
+		InterestRate:    1200,
+		TenorMonths:     12,
+		RepaymentMethod: entity.RepaymentMethodAnnuity,
+	}
+
+	instalments := repaymentSchedule(loan, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), 1)
+	assert.Len(t, instalments, 12)
+
+	// 1% a month on 1,000,000 over 12 months is 88,848.79 a month
+	var principal int64
+	for i, inst := range instalments {
+		principal += inst.Principal.Amount
+		if i < len(instalments)-1 {
+			assert.Equal(t, int64(88849), inst.Amount.Amount)
+		}
+	}
+	assert.Equal(t, int64(10000), instalments[0].Interest.Amount)
+	assert.Equal(t, int64(78849), instalments[0].Principal.Amount)
+	assert.Equal(t, int64(1000000), principal)
+	assert.InDelta(t, 88849, instalments[11].Amount.Amount, 10)
+	assert.Equal(t, int64(0), instalments[11].OutstandingPrincipal.Amount)
+}
+
+func Test_AnnuityScheduleZeroRate(t *testing.T) {
+	t.Parallel()
+
+	loan := &entity.Loan{
+		PrincipalAmount: entity.NewMoney(100, "IDR"),
+		TenorMonths:     3,
+		RepaymentMethod: entity.RepaymentMethodAnnuity,
+	}
+
+	instalments := repaymentSchedule(loan, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), 1)
+	assert.Equal(t, []int64{33, 33, 34}, []int64{instalments[0].Amount.Amount, instalments[1].Amount.Amount, instalments[2].Amount.Amount})
+}
+
+func Test_AddMonths(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), addMonths(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), 1))
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), addMonths(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), 1))
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), addMonths(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), 2))
+	assert.Equal(t, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), addMonths(time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC), 2))
+}
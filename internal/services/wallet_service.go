@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+)
+
+//go:generate mockgen -source=wallet_service.go -package=mock -destination=mock/wallet_service_mock.go
+type (
+	WalletService interface {
+		TopUp(ctx context.Context, topUpRequest entity.WalletTopUpRequest) (*entity.WalletTopUp, error)
+		ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error)
+	}
+
+	walletService struct {
+		repo WalletRepo
+	}
+
+	// WalletRepo keeps the wallet balances, holds are placed, captured and released by LoanRepo
+	// together with the pledge, disbursement or cancellation they belong to
+	WalletRepo interface {
+		TopUp(ctx context.Context, topUp entity.WalletTopUp) error
+		ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error)
+	}
+)
+
+func NewWalletService(repo WalletRepo) *walletService {
+	return &walletService{
+		repo: repo,
+	}
+}
+
+func (s *walletService) TopUp(ctx context.Context, topUpRequest entity.WalletTopUpRequest) (*entity.WalletTopUp, error) {
+
+	if err := validateAmount(topUpRequest.Amount); err != nil {
+		return nil, err
+	}
+
+	topUp := entity.WalletTopUp{
+		ID:         uuid.New(),
+		InvestorID: topUpRequest.InvestorID,
+		Amount:     topUpRequest.Amount,
+	}
+
+	if err := s.repo.TopUp(ctx, topUp); err != nil {
+		log.Printf("[TopUp] error topping up wallet: %s", err.Error())
+		return nil, err
+	}
+
+	log.Printf("[TopUp] staff %s credited %s to investor %s", topUpRequest.StaffID, topUp.Amount.String(), topUp.InvestorID)
+	return &topUp, nil
+}
+
+func (s *walletService) ListWallets(ctx context.Context, investorID uuid.UUID) ([]entity.Wallet, error) {
+
+	wallets, err := s.repo.ListWallets(ctx, investorID)
+	if err != nil {
+		log.Printf("[ListWallets] error listing wallets: %s", err.Error())
+		return nil, err
+	}
+
+	return wallets, nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func setupWalletService(t *testing.T) (*walletService, *mock.MockWalletRepo) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockWalletRepo(ctrl)
+
+	return NewWalletService(repo), repo
+}
+
+func Test_TopUp(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupWalletService(t)
+	ctx := context.Background()
+	investorID := uuid.New()
+
+	t.Run("top up failed, invalid amount", func(t *testing.T) {
+		_, err := svc.TopUp(ctx, entity.WalletTopUpRequest{InvestorID: investorID, Amount: entity.NewMoney(0, "IDR")})
+		assert.True(t, errors.Is(err, apperror.ErrInvalidAmount))
+	})
+
+	t.Run("top up failed, repo error", func(t *testing.T) {
+		repo.EXPECT().TopUp(ctx, gomock.Any()).Return(errors.New("db down"))
+
+		_, err := svc.TopUp(ctx, entity.WalletTopUpRequest{InvestorID: investorID, Amount: entity.NewMoney(5000, "IDR")})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("top up success", func(t *testing.T) {
+		var stored entity.WalletTopUp
+		repo.EXPECT().TopUp(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, topUp entity.WalletTopUp) error {
+			stored = topUp
+			return nil
+		})
+
+		topUp, err := svc.TopUp(ctx, entity.WalletTopUpRequest{InvestorID: investorID, Amount: entity.NewMoney(5000, "IDR")})
+		assert.Nil(t, err)
+		assert.Equal(t, stored, *topUp)
+		assert.NotEqual(t, uuid.Nil, topUp.ID)
+		assert.Equal(t, investorID, topUp.InvestorID)
+		assert.Equal(t, entity.NewMoney(5000, "IDR"), topUp.Amount)
+	})
+}
+
+func Test_ListWallets(t *testing.T) {
+	t.Parallel()
+
+	svc, repo := setupWalletService(t)
+	ctx := context.Background()
+	investorID := uuid.New()
+
+	t.Run("list wallets failed", func(t *testing.T) {
+		repo.EXPECT().ListWallets(ctx, investorID).Return(nil, errors.New("db down"))
+
+		_, err := svc.ListWallets(ctx, investorID)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("list wallets success", func(t *testing.T) {
+		wallets := []entity.Wallet{{InvestorID: investorID, Available: entity.NewMoney(400, "IDR"), Held: entity.NewMoney(600, "IDR")}}
+		repo.EXPECT().ListWallets(ctx, investorID).Return(wallets, nil)
+
+		res, err := svc.ListWallets(ctx, investorID)
+		assert.Nil(t, err)
+		assert.Equal(t, wallets, res)
+	})
+}
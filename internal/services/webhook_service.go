@@ -0,0 +1,349 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+const (
+	defaultWebhookBatchSize = 50
+	webhookLease            = 5 * time.Minute //how long a claimed delivery is reserved for the job sending it
+	webhookMinBackoff       = 10 * time.Second
+	webhookMaxBackoff       = time.Hour
+	webhookMaxAttempts      = 12 //about three and a half hours of retries before a delivery is dead-lettered
+	webhookMinSecretLength  = 16
+
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 200
+
+	// WebhookSignatureHeader carries t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>" keyed with the secret>
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+var ErrInvalidDeliveryFilter = apperror.Validation("invalid_filter", "delivery filter is invalid")
+
+//go:generate mockgen -source=webhook_service.go -package=mock -destination=mock/webhook_service_mock.go
+type (
+	WebhookService interface {
+		CreateWebhook(ctx context.Context, createRequest entity.WebhookCreateRequest) (*entity.WebhookSubscription, error)
+		ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error)
+		GetWebhook(ctx context.Context, webhookID uuid.UUID) (*entity.WebhookSubscription, error)
+		UpdateWebhook(ctx context.Context, updateRequest entity.WebhookUpdateRequest) (*entity.WebhookSubscription, error)
+		DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error
+		ListDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error)
+		RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*entity.WebhookDelivery, error)
+		DeliverPending(ctx context.Context) (int, error)
+	}
+
+	webhookService struct {
+		repo      WebhookRepo
+		client    HTTPDoer
+		batchSize int
+		now       func() time.Time
+	}
+
+	// WebhookRepo keeps the webhooks and their deliveries, deliveries are queued by LoanRepo together with
+	// the status change they are about
+	WebhookRepo interface {
+		CreateWebhook(ctx context.Context, webhook *entity.WebhookSubscription) error
+		ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error)
+		GetWebhook(ctx context.Context, webhookID uuid.UUID) (*entity.WebhookSubscription, error)
+		UpdateWebhook(ctx context.Context, webhook *entity.WebhookSubscription) error
+		DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error
+		ListDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error)
+		// ClaimDeliveries reserves pending deliveries for lease, oldest first and at most one per webhook and loan
+		ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+		MarkDelivered(ctx context.Context, deliveryID uuid.UUID, statusCode int) error
+		MarkFailed(ctx context.Context, deliveryID uuid.UUID, statusCode int, reason string, retryAt time.Time, dead bool) error
+		RequeueDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*entity.WebhookDelivery, error)
+	}
+
+	// HTTPDoer sends HTTP requests, *http.Client in production
+	HTTPDoer interface {
+		Do(req *http.Request) (*http.Response, error)
+	}
+)
+
+func NewWebhookService(repo WebhookRepo, client HTTPDoer, batchSize int) *webhookService {
+	if batchSize <= 0 {
+		batchSize = defaultWebhookBatchSize
+	}
+	return &webhookService{
+		repo:      repo,
+		client:    client,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, createRequest entity.WebhookCreateRequest) (*entity.WebhookSubscription, error) {
+
+	if err := validateWebhook(createRequest.URL, createRequest.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := createRequest.Secret
+	if secret == "" {
+		generated, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	} else if len(secret) < webhookMinSecretLength {
+		return nil, apperror.ErrInvalidWebhook.Withf("secret must be at least %d characters long", webhookMinSecretLength)
+	}
+
+	webhook := entity.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        createRequest.URL,
+		Secret:     secret,
+		EventTypes: normalizeEventTypes(createRequest.EventTypes),
+		Active:     true,
+		CreatedBy:  createRequest.StaffID,
+	}
+
+	if err := s.repo.CreateWebhook(ctx, &webhook); err != nil {
+		log.Printf("[CreateWebhook] error creating webhook: %s", err.Error())
+		return nil, err
+	}
+
+	log.Printf("[CreateWebhook] staff %s subscribed %s to %v", webhook.CreatedBy, webhook.URL, webhook.EventTypes)
+	return &webhook, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error) {
+
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		log.Printf("[ListWebhooks] error listing webhooks: %s", err.Error())
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*entity.WebhookSubscription, error) {
+
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		log.Printf("[GetWebhook] error getting webhook: %s", err.Error())
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, updateRequest entity.WebhookUpdateRequest) (*entity.WebhookSubscription, error) {
+
+	webhook, err := s.repo.GetWebhook(ctx, updateRequest.ID)
+	if err != nil {
+		log.Printf("[UpdateWebhook] error getting webhook: %s", err.Error())
+		return nil, err
+	}
+
+	if updateRequest.URL != nil {
+		webhook.URL = *updateRequest.URL
+	}
+	if updateRequest.EventTypes != nil {
+		webhook.EventTypes = normalizeEventTypes(*updateRequest.EventTypes)
+	}
+	if updateRequest.Active != nil {
+		webhook.Active = *updateRequest.Active
+	}
+
+	if err := validateWebhook(webhook.URL, webhook.EventTypes); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		log.Printf("[UpdateWebhook] error updating webhook: %s", err.Error())
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+
+	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
+		log.Printf("[DeleteWebhook] error deleting webhook: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context, filter entity.WebhookDeliveryFilter) ([]entity.WebhookDelivery, error) {
+
+	switch filter.Status {
+	case "", entity.WebhookDeliveryStatusPending, entity.WebhookDeliveryStatusDelivered, entity.WebhookDeliveryStatusDead:
+	default:
+		return nil, ErrInvalidDeliveryFilter.Withf("unknown delivery status %q", filter.Status)
+	}
+	if filter.Limit < 0 || filter.Limit > maxDeliveryLogLimit {
+		return nil, ErrInvalidDeliveryFilter.Withf("limit must be between 1 and %d", maxDeliveryLogLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultDeliveryLogLimit
+	}
+
+	//an unknown webhook is a 404 rather than an empty log
+	if _, err := s.GetWebhook(ctx, filter.WebhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, filter)
+	if err != nil {
+		log.Printf("[ListDeliveries] error listing deliveries: %s", err.Error())
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery sends a dead-lettered delivery again, with a fresh set of attempts
+func (s *webhookService) RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*entity.WebhookDelivery, error) {
+
+	delivery, err := s.repo.RequeueDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		log.Printf("[RetryDelivery] error requeueing delivery: %s", err.Error())
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// DeliverPending posts a batch of pending deliveries to their webhooks and returns how many were delivered. Any 2xx
+// answer is a success, anything else is retried with an exponential backoff and dead-lettered after
+// webhookMaxAttempts attempts. Delivery is at least once, partners deduplicate on the event_id of the body.
+func (s *webhookService) DeliverPending(ctx context.Context) (int, error) {
+
+	deliveries, err := s.repo.ClaimDeliveries(ctx, s.batchSize, webhookLease)
+	if err != nil {
+		log.Printf("[DeliverPending] error claiming deliveries: %s", err.Error())
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		statusCode, err := s.post(ctx, delivery)
+		if err != nil {
+			log.Printf("[DeliverPending] error delivering %s to %s: %s", delivery.ID, delivery.URL, err.Error())
+
+			attempts := delivery.Attempts + 1
+			retryAt := s.now().Add(exponentialBackoff(delivery.Attempts, webhookMinBackoff, webhookMaxBackoff))
+			err := s.repo.MarkFailed(ctx, delivery.ID, statusCode, err.Error(), retryAt, attempts >= webhookMaxAttempts)
+			if err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkDelivered(ctx, delivery.ID, statusCode); err != nil {
+			log.Printf("[DeliverPending] error marking delivery %s delivered: %s", delivery.ID, err.Error())
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// post sends the delivery and returns the status code answered, 0 when there was no answer
+func (s *webhookService) post(ctx context.Context, delivery entity.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "loan-service-webhooks")
+	req.Header.Set("X-Webhook-ID", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set(WebhookSignatureHeader, signWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	//a short excerpt of the answer helps partners debug a rejected delivery
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s: %s", resp.Status, strings.TrimSpace(string(excerpt)))
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook signs the body sent at timestamp. Including the timestamp lets partners refuse replayed deliveries.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func validateWebhook(rawURL string, eventTypes []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperror.ErrInvalidWebhook.Withf("%q is not an absolute http(s) URL", rawURL)
+	}
+
+	for _, eventType := range eventTypes {
+		known := false
+		for _, t := range entity.LoanEventTypes {
+			known = known || t == eventType
+		}
+		if !known {
+			return apperror.ErrInvalidWebhook.Withf("unknown event type %q, expected one of %s", eventType,
+				strings.Join(entity.LoanEventTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+// normalizeEventTypes drops duplicates, an empty list subscribes to every event
+func normalizeEventTypes(eventTypes []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, t := range eventTypes {
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized
+}
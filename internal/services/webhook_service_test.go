@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mock "github.com/ferdikurniawan/loan-service/internal/services/mock"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ferdikurniawan/loan-service/internal/entity"
+	"github.com/ferdikurniawan/loan-service/internal/pkg/apperror"
+)
+
+func webhookDelivery(t *testing.T, url string) entity.WebhookDelivery {
+	t.Helper()
+
+	event := loanEvent(t, entity.LoanStatusInvested)
+	payload, err := json.Marshal(event)
+	assert.Nil(t, err)
+
+	return entity.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: uuid.New(),
+		EventID:   event.ID,
+		EventType: event.Type,
+		LoanID:    event.AggregateID,
+		Payload:   payload,
+		Status:    entity.WebhookDeliveryStatusPending,
+		URL:       url,
+		Secret:    "whsec_partner_secret",
+	}
+}
+
+func Test_CreateWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockWebhookRepo(ctrl)
+	svc := NewWebhookService(repo, http.DefaultClient, 0)
+	ctx := context.Background()
+	staffID := uuid.New()
+
+	t.Run("create webhook failed, invalid url or event type or secret", func(t *testing.T) {
+		for _, req := range []entity.WebhookCreateRequest{
+			{URL: "partner.example.com/hooks"},
+			{URL: "ftp://partner.example.com/hooks"},
+			{URL: "https://partner.example.com/hooks", EventTypes: []string{"loan.proposed"}},
+			{URL: "https://partner.example.com/hooks", Secret: "short"},
+		} {
+			_, err := svc.CreateWebhook(ctx, req)
+			assert.ErrorIs(t, err, apperror.ErrInvalidWebhook, req)
+		}
+	})
+
+	t.Run("create webhook success, a secret is generated and duplicate event types are dropped", func(t *testing.T) {
+		repo.EXPECT().CreateWebhook(ctx, gomock.Any()).Return(nil)
+
+		webhook, err := svc.CreateWebhook(ctx, entity.WebhookCreateRequest{
+			URL:        "https://partner.example.com/hooks",
+			EventTypes: []string{"loan.invested", "loan.disbursed", "loan.invested"},
+			StaffID:    staffID,
+		})
+		assert.Nil(t, err)
+		assert.True(t, webhook.Active)
+		assert.Equal(t, staffID, webhook.CreatedBy)
+		assert.Equal(t, []string{"loan.invested", "loan.disbursed"}, webhook.EventTypes)
+		assert.Len(t, webhook.Secret, len("whsec_")+64)
+	})
+}
+
+func Test_UpdateWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockWebhookRepo(ctrl)
+	svc := NewWebhookService(repo, http.DefaultClient, 0)
+	ctx := context.Background()
+
+	current := func() *entity.WebhookSubscription {
+		return &entity.WebhookSubscription{ID: uuid.New(), URL: "https://partner.example.com/hooks", EventTypes: []string{}, Active: true}
+	}
+
+	t.Run("update webhook failed, webhook not found", func(t *testing.T) {
+		id := uuid.New()
+		repo.EXPECT().GetWebhook(ctx, id).Return(nil, apperror.ErrWebhookNotFound)
+
+		_, err := svc.UpdateWebhook(ctx, entity.WebhookUpdateRequest{ID: id})
+		assert.ErrorIs(t, err, apperror.ErrWebhookNotFound)
+	})
+
+	t.Run("update webhook success, only the fields set are changed", func(t *testing.T) {
+		webhook := current()
+		active := false
+		repo.EXPECT().GetWebhook(ctx, webhook.ID).Return(webhook, nil)
+		repo.EXPECT().UpdateWebhook(ctx, webhook).Return(nil)
+
+		updated, err := svc.UpdateWebhook(ctx, entity.WebhookUpdateRequest{ID: webhook.ID, Active: &active})
+		assert.Nil(t, err)
+		assert.False(t, updated.Active)
+		assert.Equal(t, "https://partner.example.com/hooks", updated.URL)
+	})
+}
+
+func Test_ListDeliveries(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockWebhookRepo(ctrl)
+	svc := NewWebhookService(repo, http.DefaultClient, 0)
+	ctx := context.Background()
+	webhookID := uuid.New()
+
+	t.Run("list deliveries failed, invalid filter", func(t *testing.T) {
+		_, err := svc.ListDeliveries(ctx, entity.WebhookDeliveryFilter{WebhookID: webhookID, Status: "lost"})
+		assert.ErrorIs(t, err, ErrInvalidDeliveryFilter)
+
+		_, err = svc.ListDeliveries(ctx, entity.WebhookDeliveryFilter{WebhookID: webhookID, Limit: maxDeliveryLogLimit + 1})
+		assert.ErrorIs(t, err, ErrInvalidDeliveryFilter)
+	})
+
+	t.Run("list deliveries failed, webhook not found", func(t *testing.T) {
+		repo.EXPECT().GetWebhook(ctx, webhookID).Return(nil, apperror.ErrWebhookNotFound)
+
+		_, err := svc.ListDeliveries(ctx, entity.WebhookDeliveryFilter{WebhookID: webhookID})
+		assert.ErrorIs(t, err, apperror.ErrWebhookNotFound)
+	})
+
+	t.Run("list deliveries success, default limit", func(t *testing.T) {
+		repo.EXPECT().GetWebhook(ctx, webhookID).Return(&entity.WebhookSubscription{ID: webhookID}, nil)
+		repo.EXPECT().ListDeliveries(ctx, entity.WebhookDeliveryFilter{
+			WebhookID: webhookID, Status: entity.WebhookDeliveryStatusDead, Limit: defaultDeliveryLogLimit,
+		}).Return([]entity.WebhookDelivery{}, nil)
+
+		deliveries, err := svc.ListDeliveries(ctx, entity.WebhookDeliveryFilter{WebhookID: webhookID, Status: entity.WebhookDeliveryStatusDead})
+		assert.Nil(t, err)
+		assert.Empty(t, deliveries)
+	})
+}
+
+func Test_DeliverPending(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mock.NewMockWebhookRepo(ctrl)
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	newService := func(batchSize int) *webhookService {
+		svc := NewWebhookService(repo, http.DefaultClient, batchSize)
+		svc.now = func() time.Time { return now }
+		return svc
+	}
+
+	t.Run("deliver pending failed, error claiming deliveries", func(t *testing.T) {
+		repo.EXPECT().ClaimDeliveries(ctx, defaultWebhookBatchSize, webhookLease).Return(nil, errors.New("db error"))
+
+		_, err := newService(0).DeliverPending(ctx)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("deliver pending success, the event envelope is posted with its signature", func(t *testing.T) {
+		var (
+			gotBody   []byte
+			gotHeader http.Header
+		)
+		partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotBody, _ = io.ReadAll(r.Body)
+			gotHeader = r.Header.Clone()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer partner.Close()
+
+		delivery := webhookDelivery(t, partner.URL)
+		repo.EXPECT().ClaimDeliveries(ctx, 10, webhookLease).Return([]entity.WebhookDelivery{delivery}, nil)
+		repo.EXPECT().MarkDelivered(ctx, delivery.ID, http.StatusNoContent).Return(nil)
+
+		delivered, err := newService(10).DeliverPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, delivered)
+
+		assert.JSONEq(t, string(delivery.Payload), string(gotBody))
+		assert.Equal(t, "application/json", gotHeader.Get("Content-Type"))
+		assert.Equal(t, delivery.ID.String(), gotHeader.Get("X-Webhook-ID"))
+		assert.Equal(t, "loan.invested", gotHeader.Get("X-Webhook-Event"))
+		assert.Equal(t, signWebhook("whsec_partner_secret", now.Unix(), gotBody), gotHeader.Get(WebhookSignatureHeader))
+	})
+
+	t.Run("deliver pending success, a rejected delivery is retried later with the answer recorded", func(t *testing.T) {
+		partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		}))
+		defer partner.Close()
+
+		delivery := webhookDelivery(t, partner.URL)
+		delivery.Attempts = 3
+		repo.EXPECT().ClaimDeliveries(ctx, 10, webhookLease).Return([]entity.WebhookDelivery{delivery}, nil)
+		repo.EXPECT().MarkFailed(ctx, delivery.ID, http.StatusServiceUnavailable, "webhook answered 503 Service Unavailable: maintenance",
+			now.Add(8*webhookMinBackoff), false).Return(nil)
+
+		delivered, err := newService(10).DeliverPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, delivered)
+	})
+
+	t.Run("deliver pending success, an unreachable webhook is dead-lettered after its last attempt", func(t *testing.T) {
+		partner := httptest.NewServer(http.NotFoundHandler())
+		url := partner.URL
+		partner.Close()
+
+		delivery := webhookDelivery(t, url)
+		delivery.Attempts = webhookMaxAttempts - 1
+		repo.EXPECT().ClaimDeliveries(ctx, 10, webhookLease).Return([]entity.WebhookDelivery{delivery}, nil)
+		repo.EXPECT().MarkFailed(ctx, delivery.ID, 0, gomock.Any(), gomock.Any(), true).Return(nil)
+
+		delivered, err := newService(10).DeliverPending(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, delivered)
+	})
+}
+
+func Test_SignWebhook(t *testing.T) {
+	t.Parallel()
+
+	//echo -n '1717236000.{"event_id":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "t=1717236000,v1=7dac17479e24f06f31f43f4165dea5c44af4b872080c509f6566e1a9cbfc5fec",
+		signWebhook("secret", 1717236000, []byte(`{"event_id":"1"}`)))
+}
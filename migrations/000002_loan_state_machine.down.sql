@@ -0,0 +1,14 @@
+ALTER TABLE loan_status_history DROP COLUMN IF EXISTS reason;
+
+-- postgres cannot drop enum values, recreate the type without the terminal statuses
+UPDATE loan SET status = 'proposed' WHERE status IN ('rejected', 'cancelled', 'expired');
+
+ALTER TYPE loan_status RENAME TO loan_status_old;
+
+CREATE TYPE loan_status AS ENUM (
+'proposed','approved','invested','disbursed'
+);
+
+ALTER TABLE loan ALTER COLUMN status TYPE loan_status USING status::text::loan_status;
+
+DROP TYPE loan_status_old;
@@ -0,0 +1,5 @@
+ALTER TYPE loan_status ADD VALUE IF NOT EXISTS 'rejected';
+ALTER TYPE loan_status ADD VALUE IF NOT EXISTS 'cancelled';
+ALTER TYPE loan_status ADD VALUE IF NOT EXISTS 'expired';
+
+ALTER TABLE loan_status_history ADD COLUMN reason text;
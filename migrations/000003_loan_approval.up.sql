@@ -0,0 +1,8 @@
+CREATE TABLE loan_approval (
+    loan_id uuid PRIMARY KEY REFERENCES loan (loan_id),
+    picture_proof text NOT NULL,
+    field_validator_id uuid NOT NULL,
+    approved_by uuid NOT NULL,
+    approval_date date NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
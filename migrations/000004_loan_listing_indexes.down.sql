@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS loan_investment_loan_id_idx;
+DROP INDEX IF EXISTS loan_borrower_id_created_at_idx;
+DROP INDEX IF EXISTS loan_created_at_loan_id_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS loan_created_at_loan_id_idx ON loan (created_at, loan_id);
+CREATE INDEX IF NOT EXISTS loan_borrower_id_created_at_idx ON loan (borrower_id, created_at);
+CREATE INDEX IF NOT EXISTS loan_investment_loan_id_idx ON loan_investment (loan_id);
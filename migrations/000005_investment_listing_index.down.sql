@@ -0,0 +1 @@
+DROP INDEX IF EXISTS loan_investment_investor_id_idx;
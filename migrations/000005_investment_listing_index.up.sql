@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS loan_investment_investor_id_idx ON loan_investment (investor_id, invested_at);
@@ -0,0 +1,7 @@
+ALTER TABLE loan ADD COLUMN interest_rate bigint;
+UPDATE loan SET interest_rate = interest_rate_bps / 100;
+ALTER TABLE loan ALTER COLUMN interest_rate SET NOT NULL;
+ALTER TABLE loan DROP COLUMN interest_rate_bps;
+
+ALTER TABLE loan_investment DROP COLUMN currency;
+ALTER TABLE loan DROP COLUMN currency;
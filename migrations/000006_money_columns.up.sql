@@ -0,0 +1,13 @@
+-- amounts stay in minor units, each one now carries its ISO 4217 currency
+ALTER TABLE loan ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE loan_investment ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE loan ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE loan_investment ALTER COLUMN currency DROP DEFAULT;
+
+-- interest rate is stored in basis points (1050 = 10.50%), the former bigint column only held whole percents
+ALTER TABLE loan ADD COLUMN interest_rate_bps bigint;
+UPDATE loan SET interest_rate_bps = interest_rate * 100;
+ALTER TABLE loan ALTER COLUMN interest_rate_bps SET NOT NULL;
+ALTER TABLE loan ADD CONSTRAINT loan_interest_rate_bps_check CHECK (interest_rate_bps >= 0);
+ALTER TABLE loan DROP COLUMN interest_rate;
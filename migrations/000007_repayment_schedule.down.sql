@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS loan_instalment;
+
+ALTER TABLE loan DROP COLUMN repayment_method;
+ALTER TABLE loan DROP COLUMN tenor_months;
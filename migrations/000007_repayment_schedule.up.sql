@@ -0,0 +1,20 @@
+-- loan terms, loans submitted before tenors existed are treated as 12 month flat loans
+ALTER TABLE loan ADD COLUMN tenor_months integer NOT NULL DEFAULT 12;
+ALTER TABLE loan ADD COLUMN repayment_method text NOT NULL DEFAULT 'flat';
+
+ALTER TABLE loan ALTER COLUMN tenor_months DROP DEFAULT;
+ALTER TABLE loan ALTER COLUMN repayment_method DROP DEFAULT;
+
+ALTER TABLE loan ADD CONSTRAINT loan_tenor_months_check CHECK (tenor_months BETWEEN 1 AND 360);
+ALTER TABLE loan ADD CONSTRAINT loan_repayment_method_check CHECK (repayment_method IN ('flat', 'annuity'));
+
+CREATE TABLE loan_instalment (
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    instalment_number integer NOT NULL,
+    due_date date NOT NULL,
+    principal_amount bigint NOT NULL,
+    interest_amount bigint NOT NULL,
+    outstanding_principal bigint NOT NULL,
+    currency char(3) NOT NULL,
+    PRIMARY KEY (loan_id, instalment_number)
+);
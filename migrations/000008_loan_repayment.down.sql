@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS loan_repayment;
+
+ALTER TABLE loan_instalment DROP CONSTRAINT IF EXISTS loan_instalment_paid_check;
+ALTER TABLE loan_instalment DROP COLUMN fee_paid;
+ALTER TABLE loan_instalment DROP COLUMN interest_paid;
+ALTER TABLE loan_instalment DROP COLUMN principal_paid;
+ALTER TABLE loan_instalment DROP COLUMN fee_amount;
+
+-- postgres cannot drop enum values, recreate the type without the repayment statuses
+UPDATE loan SET status = 'disbursed' WHERE status IN ('repaying', 'closed');
+
+ALTER TYPE loan_status RENAME TO loan_status_old;
+
+CREATE TYPE loan_status AS ENUM (
+'proposed','approved','invested','disbursed','rejected','cancelled','expired'
+);
+
+ALTER TABLE loan ALTER COLUMN status TYPE loan_status USING status::text::loan_status;
+
+DROP TYPE loan_status_old;
@@ -0,0 +1,25 @@
+ALTER TYPE loan_status ADD VALUE IF NOT EXISTS 'repaying';
+ALTER TYPE loan_status ADD VALUE IF NOT EXISTS 'closed';
+
+ALTER TABLE loan_instalment ADD COLUMN fee_amount bigint NOT NULL DEFAULT 0;
+ALTER TABLE loan_instalment ADD COLUMN principal_paid bigint NOT NULL DEFAULT 0;
+ALTER TABLE loan_instalment ADD COLUMN interest_paid bigint NOT NULL DEFAULT 0;
+ALTER TABLE loan_instalment ADD COLUMN fee_paid bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE loan_instalment ADD CONSTRAINT loan_instalment_paid_check CHECK (
+    principal_paid BETWEEN 0 AND principal_amount
+    AND interest_paid BETWEEN 0 AND interest_amount
+    AND fee_paid BETWEEN 0 AND fee_amount
+);
+
+CREATE TABLE loan_repayment (
+    repayment_id uuid PRIMARY KEY,
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    amount bigint NOT NULL CHECK (amount > 0),
+    currency char(3) NOT NULL,
+    paid_at date NOT NULL,
+    recorded_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX loan_repayment_loan_id_idx ON loan_repayment (loan_id, created_at);
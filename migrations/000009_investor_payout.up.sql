@@ -0,0 +1,15 @@
+CREATE TABLE investor_payout (
+    payout_id uuid PRIMARY KEY,
+    repayment_id uuid NOT NULL REFERENCES loan_repayment (repayment_id),
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    loan_investment_id uuid NOT NULL REFERENCES loan_investment (loan_investment_id),
+    investor_id uuid NOT NULL,
+    principal_amount bigint NOT NULL CHECK (principal_amount >= 0),
+    interest_amount bigint NOT NULL CHECK (interest_amount >= 0),
+    currency char(3) NOT NULL,
+    paid_at date NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    UNIQUE (repayment_id, loan_investment_id)
+);
+
+CREATE INDEX investor_payout_investor_id_idx ON investor_payout (investor_id, paid_at);
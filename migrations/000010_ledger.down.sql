@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ledger_entry;
+DROP TABLE IF EXISTS ledger_transaction;
+
+DROP FUNCTION IF EXISTS ledger_transaction_balanced();
+DROP FUNCTION IF EXISTS ledger_append_only();
@@ -0,0 +1,51 @@
+CREATE TABLE ledger_transaction (
+    transaction_id uuid PRIMARY KEY,
+    kind text NOT NULL,
+    loan_id uuid,
+    reference_id uuid,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE ledger_entry (
+    entry_id BIGSERIAL PRIMARY KEY,
+    transaction_id uuid NOT NULL REFERENCES ledger_transaction (transaction_id),
+    account_type text NOT NULL,
+    account_owner uuid,
+    side text NOT NULL CHECK (side IN ('debit', 'credit')),
+    amount bigint NOT NULL CHECK (amount > 0),
+    currency char(3) NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX ledger_entry_account_idx ON ledger_entry (account_type, account_owner, currency);
+CREATE INDEX ledger_entry_transaction_id_idx ON ledger_entry (transaction_id);
+
+-- the ledger is append-only
+CREATE FUNCTION ledger_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only, % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_transaction_append_only BEFORE UPDATE OR DELETE ON ledger_transaction
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER ledger_entry_append_only BEFORE UPDATE OR DELETE ON ledger_entry
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- every transaction must balance by the time it is committed
+CREATE FUNCTION ledger_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM ledger_entry WHERE transaction_id = NEW.transaction_id
+        GROUP BY currency
+        HAVING SUM(CASE WHEN side = 'debit' THEN amount ELSE -amount END) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger transaction % does not balance', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entry_balanced AFTER INSERT ON ledger_entry
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_transaction_balanced();
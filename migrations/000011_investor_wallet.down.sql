@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_hold;
+DROP TABLE IF EXISTS investor_wallet;
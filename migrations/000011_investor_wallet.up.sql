@@ -0,0 +1,45 @@
+CREATE TABLE investor_wallet (
+    investor_id uuid NOT NULL,
+    currency char(3) NOT NULL,
+    available bigint NOT NULL DEFAULT 0 CHECK (available >= 0),
+    held bigint NOT NULL DEFAULT 0 CHECK (held >= 0),
+    updated_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (investor_id, currency)
+);
+
+CREATE TABLE wallet_hold (
+    hold_id uuid PRIMARY KEY,
+    investor_id uuid NOT NULL,
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    loan_investment_id uuid NOT NULL UNIQUE REFERENCES loan_investment (loan_investment_id),
+    amount bigint NOT NULL CHECK (amount > 0),
+    currency char(3) NOT NULL,
+    status text NOT NULL CHECK (status IN ('held', 'captured', 'released')),
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX wallet_hold_loan_id_idx ON wallet_hold (loan_id, status);
+CREATE INDEX wallet_hold_investor_id_idx ON wallet_hold (investor_id, status);
+
+-- pledges made before wallets existed: reserve them on loans that are not disbursed yet, capture the others
+INSERT INTO wallet_hold (hold_id, investor_id, loan_id, loan_investment_id, amount, currency, status, created_at, updated_at)
+SELECT i.loan_investment_id, i.investor_id, i.loan_id, i.loan_investment_id, i.amount, i.currency,
+    CASE WHEN l.status IN ('approved', 'invested') THEN 'held' ELSE 'captured' END, i.invested_at, now()
+FROM loan_investment i JOIN loan l ON l.loan_id = i.loan_id;
+
+INSERT INTO investor_wallet (investor_id, currency, available, held, updated_at)
+SELECT investor_id, currency, 0, COALESCE(SUM(amount) FILTER (WHERE status = 'held'), 0), now()
+FROM wallet_hold GROUP BY investor_id, currency;
+
+-- every investor with a pledge must have come out of the backfill with a wallet
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM wallet_hold h
+        WHERE NOT EXISTS (SELECT 1 FROM investor_wallet w WHERE w.investor_id = h.investor_id AND w.currency = h.currency)
+    ) THEN
+        RAISE EXCEPTION 'investor_wallet backfill missed investors with a wallet_hold';
+    END IF;
+END
+$$;
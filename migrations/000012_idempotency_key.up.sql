@@ -0,0 +1,13 @@
+CREATE TABLE idempotency_key (
+    subject text NOT NULL,
+    idempotency_key text NOT NULL,
+    request_hash text NOT NULL,
+    status text NOT NULL CHECK (status IN ('in_progress', 'completed')),
+    response_status integer,
+    response_body bytea,
+    created_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    PRIMARY KEY (subject, idempotency_key)
+);
+
+CREATE INDEX idempotency_key_created_at_idx ON idempotency_key (created_at);
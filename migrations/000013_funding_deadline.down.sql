@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS loan_funding_deadline_idx;
+ALTER TABLE loan DROP COLUMN IF EXISTS funding_deadline;
@@ -0,0 +1,7 @@
+ALTER TABLE loan ADD COLUMN funding_deadline timestamp with time zone;
+
+-- loans already open for investment get a full funding window from now on. The window is a fixed 30 days here,
+-- the default of FUNDING_WINDOW_DAYS, whatever the service is configured with: the setting applies to approvals only.
+UPDATE loan SET funding_deadline = now() + interval '30 days' WHERE status = 'approved';
+
+CREATE INDEX loan_funding_deadline_idx ON loan (funding_deadline) WHERE status = 'approved';
@@ -0,0 +1,2 @@
+ALTER TABLE loan_investment DROP COLUMN IF EXISTS withdrawn_at;
+ALTER TABLE loan_investment DROP COLUMN IF EXISTS status;
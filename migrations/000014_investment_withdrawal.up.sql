@@ -0,0 +1,2 @@
+ALTER TABLE loan_investment ADD COLUMN status text NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'withdrawn'));
+ALTER TABLE loan_investment ADD COLUMN withdrawn_at timestamp with time zone;
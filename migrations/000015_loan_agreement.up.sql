@@ -0,0 +1,10 @@
+CREATE TABLE loan_agreement (
+    agreement_id uuid PRIMARY KEY,
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    template_version text NOT NULL,
+    html bytea NOT NULL,
+    pdf bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX loan_agreement_loan_id_idx ON loan_agreement (loan_id, created_at DESC);
@@ -0,0 +1,16 @@
+CREATE TABLE outbox_event (
+    event_id uuid PRIMARY KEY,
+    sequence bigserial NOT NULL UNIQUE,
+    event_type text NOT NULL,
+    event_version integer NOT NULL,
+    aggregate_id uuid NOT NULL,
+    payload jsonb NOT NULL,
+    occurred_at timestamp with time zone NOT NULL,
+    published_at timestamp with time zone,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    available_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX outbox_event_pending_idx ON outbox_event (sequence) WHERE published_at IS NULL;
+CREATE INDEX outbox_event_aggregate_pending_idx ON outbox_event (aggregate_id, sequence) WHERE published_at IS NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE investor_contact (
+    investor_id uuid PRIMARY KEY,
+    email text NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE notification (
+    notification_id uuid PRIMARY KEY,
+    kind text NOT NULL,
+    loan_id uuid NOT NULL REFERENCES loan (loan_id),
+    investor_id uuid NOT NULL,
+    status text NOT NULL CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp with time zone NOT NULL,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    UNIQUE (kind, loan_id, investor_id)
+);
+
+CREATE INDEX notification_pending_idx ON notification (next_attempt_at) WHERE status = 'pending';
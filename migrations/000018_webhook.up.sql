@@ -0,0 +1,32 @@
+CREATE TABLE webhook_subscription (
+    webhook_id uuid PRIMARY KEY,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types text[] NOT NULL DEFAULT '{}',
+    active boolean NOT NULL DEFAULT true,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE webhook_delivery (
+    delivery_id uuid PRIMARY KEY,
+    sequence bigserial NOT NULL UNIQUE,
+    webhook_id uuid NOT NULL REFERENCES webhook_subscription (webhook_id) ON DELETE CASCADE,
+    event_id uuid NOT NULL REFERENCES outbox_event (event_id),
+    event_type text NOT NULL,
+    loan_id uuid NOT NULL,
+    payload jsonb NOT NULL,
+    status text NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts integer NOT NULL DEFAULT 0,
+    last_status_code integer,
+    last_error text,
+    next_attempt_at timestamp with time zone NOT NULL,
+    delivered_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_delivery_pending_idx ON webhook_delivery (sequence) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_loan_pending_idx ON webhook_delivery (webhook_id, loan_id, sequence) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_log_idx ON webhook_delivery (webhook_id, sequence DESC);
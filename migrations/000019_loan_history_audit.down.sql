@@ -0,0 +1,2 @@
+ALTER TABLE loan_status_history DROP COLUMN IF EXISTS request_id;
+ALTER TABLE loan_status_history DROP COLUMN IF EXISTS actor_type;
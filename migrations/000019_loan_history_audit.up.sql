@@ -0,0 +1,5 @@
+ALTER TABLE loan_status_history ADD COLUMN actor_type text CHECK (actor_type IN ('staff', 'borrower', 'investor', 'system'));
+ALTER TABLE loan_status_history ADD COLUMN request_id text;
+
+-- every record written with an updated_by so far was written on behalf of staff, the others cannot be attributed
+UPDATE loan_status_history SET actor_type = 'staff' WHERE updated_by IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE loan_agreement DROP COLUMN IF EXISTS document_key;
@@ -0,0 +1,2 @@
+-- copy of the letter in the document store, linked from e-mails with a signed URL
+ALTER TABLE loan_agreement ADD COLUMN document_key text NOT NULL;